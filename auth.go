@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// passwordFileTransport authenticates requests using basic auth with the
+// password read from a file. The file is re-read whenever its modification
+// time changes, so that rotated credentials are picked up without a restart.
+type passwordFileTransport struct {
+	username string
+	path     string
+	next     http.RoundTripper
+
+	mu       sync.Mutex
+	modTime  time.Time
+	password string
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (p *passwordFileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	password, err := p.getPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.SetBasicAuth(p.username, password)
+
+	return p.next.RoundTrip(req)
+}
+
+func (p *passwordFileTransport) getPassword() (string, error) {
+	fi, err := os.Stat(p.path)
+	if err != nil {
+		return "", fmt.Errorf("couldn't stat password file: %s", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !fi.ModTime().Equal(p.modTime) {
+		data, err := ioutil.ReadFile(p.path)
+		if err != nil {
+			return "", fmt.Errorf("couldn't read password file: %s", err)
+		}
+		p.password = strings.TrimRight(string(data), "\r\n")
+		p.modTime = fi.ModTime()
+	}
+
+	return p.password, nil
+}
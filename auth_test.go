@@ -0,0 +1,85 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestPasswordFileTransport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	writePassword := func(password string, modTime time.Time) {
+		t.Helper()
+		if err := ioutil.WriteFile(path, []byte(password), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var gotUser, gotPassword string
+	transport := &passwordFileTransport{
+		username: "exporter",
+		path:     path,
+		next: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			gotUser, gotPassword, _ = req.BasicAuth()
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		}),
+	}
+	roundTrip := func() error {
+		req, err := http.NewRequest(http.MethodPost, "http://localhost:9091/transmission/rpc", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = transport.RoundTrip(req)
+		return err
+	}
+
+	start := time.Unix(1600000000, 0)
+	writePassword("first\r\n", start)
+	if err := roundTrip(); err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	if gotUser != "exporter" || gotPassword != "first" {
+		t.Errorf("basic auth = %q:%q, want %q:%q", gotUser, gotPassword, "exporter", "first")
+	}
+
+	// The password is only re-read once the file modification time changes.
+	if err := ioutil.WriteFile(path, []byte("second\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, start, start); err != nil {
+		t.Fatal(err)
+	}
+	if err := roundTrip(); err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	if gotPassword != "first" {
+		t.Errorf("password with unchanged modification time = %q, want %q", gotPassword, "first")
+	}
+
+	writePassword("second\n", start.Add(time.Minute))
+	if err := roundTrip(); err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	if gotPassword != "second" {
+		t.Errorf("password after rotation = %q, want %q", gotPassword, "second")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := roundTrip(); err == nil {
+		t.Errorf("RoundTrip() with missing password file succeeded, want error")
+	}
+}
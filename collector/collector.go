@@ -3,6 +3,13 @@ package collector
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,107 +21,1509 @@ import (
 
 const namespace = "transmission"
 
+var weekdays = map[transmission.Weekday]string{
+	transmission.Sunday:    "sunday",
+	transmission.Monday:    "monday",
+	transmission.Tuesday:   "tuesday",
+	transmission.Wednesday: "wednesday",
+	transmission.Thursday:  "thursday",
+	transmission.Friday:    "friday",
+	transmission.Saturday:  "saturday",
+}
+
+// Preset selects the amount of detail exposed by TransmissionCollector.
+type Preset int
+
+const (
+	// PresetLite exposes session-wide metrics only.
+	PresetLite Preset = iota
+	// PresetStandard additionally exposes metrics aggregated over all torrents.
+	PresetStandard
+	// PresetFull additionally exposes per-torrent and per-tracker metrics.
+	PresetFull
+)
+
+// ParsePreset returns Preset with the given name.
+func ParsePreset(name string) (Preset, error) {
+	switch name {
+	case "lite":
+		return PresetLite, nil
+	case "standard":
+		return PresetStandard, nil
+	case "full":
+		return PresetFull, nil
+	}
+
+	return 0, fmt.Errorf("unknown preset %q", name)
+}
+
+// CollectorInfo describes a group of metrics that can be enabled with WithCollectors.
+type CollectorInfo struct {
+	Name string
+	// Help describes what the collector exposes.
+	Help    string
+	Default bool
+}
+
+// Collectors lists collectors that can be enabled with WithCollectors. Collectors making
+// expensive Transmission requests are disabled by default.
+var Collectors = []CollectorInfo{
+	{Name: "version", Help: "Transmission version", Default: true},
+	{Name: "port", Help: "peer port state", Default: true},
+	{Name: "turtle", Help: "turtle mode state and schedule", Default: true},
+	{Name: "stats", Help: "session statistics", Default: true},
+	{Name: "speed-limits", Help: "speed limits and their utilization", Default: true},
+	{Name: "queue", Help: "download and seed queue configuration", Default: true},
+	{Name: "blocklist", Help: "peer blocklist state", Default: true},
+	{Name: "seed-ratio", Help: "global seed ratio limit", Default: true},
+	{Name: "free-space", Help: "free space of download directories", Default: true},
+	{Name: "status", Help: "number of torrents by status (requires standard preset)", Default: true},
+	{Name: "peers", Help: "connected peers (requires standard preset)", Default: false},
+	{Name: "torrents", Help: "metrics aggregated over torrents and per-torrent metrics (requires standard preset)", Default: false},
+	{Name: "trackers", Help: "per-tracker torrent swarm statistics (requires full preset)", Default: false},
+}
+
 // TransmissionCollector implements the prometheus.Collector interface.
 type TransmissionCollector struct {
 	client *transmission.Client
 	logger log.Logger
 
-	portOpenDesc *prometheus.Desc
+	scrapeTimestampDesc         *prometheus.Desc
+	secondsSinceLastSuccessDesc *prometheus.Desc
+	scrapeGoroutinesDesc        *prometheus.Desc
+	upDesc                      *prometheus.Desc
+	scrapeDurationDesc          *prometheus.Desc
+	scrapeSuccessDesc           *prometheus.Desc
+
+	versionNumericDesc *prometheus.Desc
+	versionInfoDesc    *prometheus.Desc
 
-	turtleModeDesc *prometheus.Desc
+	portOpenDesc       *prometheus.Desc
+	portClosedSecsDesc *prometheus.Desc
+
+	turtleModeDesc        *prometheus.Desc
+	turtleScheduleDayDesc *prometheus.Desc
 
 	activeTorrentsDesc *prometheus.Desc
 	pausedTorrentsDesc *prometheus.Desc
+	pausedRatioDesc    *prometheus.Desc
 
 	downloadedBytesTotalDesc *prometheus.Desc
 	uploadedBytesTotalDesc   *prometheus.Desc
+
+	downloadSpeedDesc *prometheus.Desc
+	uploadSpeedDesc   *prometheus.Desc
+
+	currentActiveSecondsDesc   *prometheus.Desc
+	sessionCountTotalDesc      *prometheus.Desc
+	currentDownloadedBytesDesc *prometheus.Desc
+	currentUploadedBytesDesc   *prometheus.Desc
+
+	uploadLimitUtilizationDesc *prometheus.Desc
+
+	speedLimitDownDesc        *prometheus.Desc
+	speedLimitUpDesc          *prometheus.Desc
+	speedLimitDownEnabledDesc *prometheus.Desc
+	speedLimitUpEnabledDesc   *prometheus.Desc
+
+	downloadQueueSizeDesc    *prometheus.Desc
+	downloadQueueEnabledDesc *prometheus.Desc
+	seedQueueSizeDesc        *prometheus.Desc
+	seedQueueEnabledDesc     *prometheus.Desc
+
+	blocklistSizeDesc    *prometheus.Desc
+	blocklistEnabledDesc *prometheus.Desc
+
+	seedRatioLimitDesc   *prometheus.Desc
+	seedRatioLimitedDesc *prometheus.Desc
+
+	freeSpaceDesc *prometheus.Desc
+
+	torrentsByErrorDesc          *prometheus.Desc
+	torrentsDesc                 *prometheus.Desc
+	peersConnectedDesc           *prometheus.Desc
+	peersSendingDesc             *prometheus.Desc
+	peersReceivingDesc           *prometheus.Desc
+	peerLimitUtilizationDesc     *prometheus.Desc
+	torrentsQueuedCheckDesc      *prometheus.Desc
+	torrentsCheckingDesc         *prometheus.Desc
+	torrentsDownloadWaitDesc     *prometheus.Desc
+	torrentsSeedWaitDesc         *prometheus.Desc
+	torrentsStatusChangedDesc    *prometheus.Desc
+	torrentsByPriorityDesc       *prometheus.Desc
+	torrentsBySeedRatioModeDesc  *prometheus.Desc
+	torrentsByDirDesc            *prometheus.Desc
+	averageAvailabilityRatioDesc *prometheus.Desc
+	torrentsIncompleteOldDesc    *prometheus.Desc
+	latestTorrentAddedDesc       *prometheus.Desc
+	totalLeftBytesDesc           *prometheus.Desc
+	sufficientSpaceDesc          *prometheus.Desc
+	labelDownloadRateDesc        *prometheus.Desc
+	labelUploadRateDesc          *prometheus.Desc
+	downloadQueueSlotsFreeDesc   *prometheus.Desc
+
+	distinctTrackersDesc       *prometheus.Desc
+	trackersAnnouncingDesc     *prometheus.Desc
+	trackerDownloadedCountDesc *prometheus.Desc
+	trackerDownSecondsDesc     *prometheus.Desc
+	trackerSeederCountDesc     *prometheus.Desc
+	trackerLeecherCountDesc    *prometheus.Desc
+	trackerAnnounceErrorsDesc  *prometheus.Desc
+
+	torrentProgressRateDesc       *prometheus.Desc
+	torrentEstimatedETADesc       *prometheus.Desc
+	torrentInIncompleteDirDesc    *prometheus.Desc
+	torrentDownloadEfficiencyDesc *prometheus.Desc
+	torrentSeedingStoppedDesc     *prometheus.Desc
+	torrentTrackerWarningsDesc    *prometheus.Desc
+	torrentSeedPeerRatioDesc      *prometheus.Desc
+	torrentWebseedCountDesc       *prometheus.Desc
+	torrentActiveTrackersDesc     *prometheus.Desc
+	torrentTotalTrackersDesc      *prometheus.Desc
+	torrentDownloadLimitDesc      *prometheus.Desc
+	torrentDownloadLimitedDesc    *prometheus.Desc
+	torrentWantedCoverageDesc     *prometheus.Desc
+	torrentDistinctPeerIPsDesc    *prometheus.Desc
+	torrentHasMetadataDesc        *prometheus.Desc
+	torrentProgressRatioDesc      *prometheus.Desc
+	torrentDownloadSpeedDesc      *prometheus.Desc
+	torrentUploadSpeedDesc        *prometheus.Desc
+	torrentSizeDesc               *prometheus.Desc
+	torrentDownloadedDesc         *prometheus.Desc
+	torrentUploadedDesc           *prometheus.Desc
+	torrentActiveFileInfoDesc     *prometheus.Desc
+	torrentVerifiedPiecesDesc     *prometheus.Desc
+	torrentSecondsToRatioGoalDesc *prometheus.Desc
+	torrentEffectiveRatioDesc     *prometheus.Desc
+	torrentErrorDesc              *prometheus.Desc
+	torrentETADesc                *prometheus.Desc
+	torrentSinceActiveDesc        *prometheus.Desc
+	torrentDownloadingPeersDesc   *prometheus.Desc
+	torrentDirFreeSpaceDesc       *prometheus.Desc
+	torrentRatioDesc              *prometheus.Desc
+	torrentAnnouncePeerCountDesc  *prometheus.Desc
+
+	preset Preset
+
+	freeSpacePaths []string
+
+	incompleteAgeThreshold time.Duration
+	torrentWebseeds        bool
+	torrentPeers           bool
+	torrentFiles           bool
+	minTorrentRate         int64
+
+	verboseHelp bool
+
+	aggregatesOnly bool
+
+	collectors map[string]bool
+
+	rpcVersion int
+	stateless  bool
+
+	timeout time.Duration
+
+	denylist  []*regexp.Regexp
+	allowlist map[string]bool
+	denied    map[*prometheus.Desc]bool
+
+	lastSuccessMu sync.Mutex
+	lastSuccess   time.Time
+
+	portCheckInterval time.Duration
+
+	portMu          sync.Mutex
+	portOpen        bool
+	portCheckedAt   time.Time
+	portClosedSince time.Time
+
+	torrentsMu        sync.Mutex
+	torrents          map[string]*torrentState
+	trackersDownSince map[string]time.Time
+}
+
+// Option configures TransmissionCollector.
+type Option func(*TransmissionCollector)
+
+// WithMetricDenylist suppresses emission of metrics whose name matches any of denylist.
+func WithMetricDenylist(denylist []*regexp.Regexp) Option {
+	return func(t *TransmissionCollector) {
+		t.denylist = denylist
+	}
+}
+
+// WithMetricAllowlist limits emitted metrics to the ones named in allowlist. Empty
+// allowlist doesn't limit anything.
+func WithMetricAllowlist(allowlist []string) Option {
+	return func(t *TransmissionCollector) {
+		if len(allowlist) == 0 {
+			t.allowlist = nil
+			return
+		}
+
+		t.allowlist = make(map[string]bool, len(allowlist))
+		for _, name := range allowlist {
+			t.allowlist[name] = true
+		}
+	}
+}
+
+// WithPreset sets the amount of detail exposed by the collector.
+func WithPreset(preset Preset) Option {
+	return func(t *TransmissionCollector) {
+		t.preset = preset
+	}
+}
+
+// WithFreeSpacePaths enables reporting of free space available in paths in addition to the download directory.
+func WithFreeSpacePaths(paths []string) Option {
+	return func(t *TransmissionCollector) {
+		t.freeSpacePaths = paths
+	}
+}
+
+// WithIncompleteAgeThreshold sets the age after which incomplete torrents are considered old.
+func WithIncompleteAgeThreshold(threshold time.Duration) Option {
+	return func(t *TransmissionCollector) {
+		t.incompleteAgeThreshold = threshold
+	}
+}
+
+// WithTorrentWebseeds enables reporting of per-torrent webseed counts. Requires PresetFull.
+func WithTorrentWebseeds(enabled bool) Option {
+	return func(t *TransmissionCollector) {
+		t.torrentWebseeds = enabled
+	}
+}
+
+// WithTorrentPeers enables reporting of metrics derived from per-torrent peer details. Requires PresetFull.
+func WithTorrentPeers(enabled bool) Option {
+	return func(t *TransmissionCollector) {
+		t.torrentPeers = enabled
+	}
+}
+
+// WithTorrentFiles enables reporting of metrics derived from per-torrent file details. Requires PresetFull.
+func WithTorrentFiles(enabled bool) Option {
+	return func(t *TransmissionCollector) {
+		t.torrentFiles = enabled
+	}
+}
+
+// WithMinTorrentRate limits per-torrent metrics to torrents whose combined download and
+// upload rate exceeds rate bytes per second. Zero disables the filter.
+func WithMinTorrentRate(rate int64) Option {
+	return func(t *TransmissionCollector) {
+		t.minTorrentRate = rate
+	}
+}
+
+// WithVerboseHelp includes source Transmission RPC fields into metric help texts.
+func WithVerboseHelp(enabled bool) Option {
+	return func(t *TransmissionCollector) {
+		t.verboseHelp = enabled
+	}
+}
+
+// WithCollectors limits collection to the named collectors, see Collectors. Without this
+// option collectors enabled by default are used.
+func WithCollectors(names []string) Option {
+	return func(t *TransmissionCollector) {
+		t.collectors = make(map[string]bool, len(names))
+		for _, name := range names {
+			t.collectors[name] = true
+		}
+	}
+}
+
+// WithAggregatesOnly suppresses per-torrent metrics while keeping metrics aggregated over
+// torrents of PresetFull.
+func WithAggregatesOnly(enabled bool) Option {
+	return func(t *TransmissionCollector) {
+		t.aggregatesOnly = enabled
+	}
+}
+
+// WithRPCVersion limits requested data to what Transmission speaking the given RPC
+// version supports. Zero means the version is unknown and everything is requested.
+func WithRPCVersion(version int) Option {
+	return func(t *TransmissionCollector) {
+		t.rpcVersion = version
+	}
+}
+
+// WithStateless drops metrics derived from previous scrapes, as well as the peer port
+// check, for collectors that serve a single scrape only.
+func WithStateless(enabled bool) Option {
+	return func(t *TransmissionCollector) {
+		t.stateless = enabled
+	}
+}
+
+// WithTimeout sets how long the collector waits for each group of Transmission RPC requests.
+func WithTimeout(timeout time.Duration) Option {
+	return func(t *TransmissionCollector) {
+		t.timeout = timeout
+	}
+}
+
+// WithPortCheckInterval sets how often Transmission is asked to test whether the peer port
+// is open. The last result is reported in between. Zero tests the port on every scrape.
+func WithPortCheckInterval(interval time.Duration) Option {
+	return func(t *TransmissionCollector) {
+		t.portCheckInterval = interval
+	}
 }
 
 // NewTransmissionCollector creates a new collector for Transmission connected to client.
-func NewTransmissionCollector(client *transmission.Client, logger log.Logger) (*TransmissionCollector, error) {
-	return &TransmissionCollector{
+func NewTransmissionCollector(client *transmission.Client, logger log.Logger, opts ...Option) (*TransmissionCollector, error) {
+	t := &TransmissionCollector{
 		client: client,
 		logger: logger,
 
-		portOpenDesc: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "", "is_port_open"),
-			"Indicates whether or not the peer port is accessible from the internet.",
-			nil, nil,
-		),
-
-		turtleModeDesc: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "", "is_turtle_mode_active"),
-			"Indicates whether or not turtle mode is active.",
-			nil, nil,
-		),
-
-		activeTorrentsDesc: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "", "active_torrents"),
-			"Number of active torrents.",
-			nil, nil,
-		),
-		pausedTorrentsDesc: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "", "paused_torrents"),
-			"Number of paused torrents.",
-			nil, nil,
-		),
-
-		downloadedBytesTotalDesc: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "", "downloaded_bytes_total"),
-			"Total amount of downloaded data.",
-			nil, nil,
-		),
-		uploadedBytesTotalDesc: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "", "uploaded_bytes_total"),
-			"Total amount of uploaded data.",
-			nil, nil,
-		),
-	}, nil
+		preset: PresetFull,
+
+		incompleteAgeThreshold: 30 * 24 * time.Hour,
+
+		timeout: 5 * time.Second,
+
+		denied: make(map[*prometheus.Desc]bool),
+
+		lastSuccess: time.Now(),
+
+		torrents:          make(map[string]*torrentState),
+		trackersDownSince: make(map[string]time.Time),
+
+		collectors: make(map[string]bool),
+	}
+	for _, c := range Collectors {
+		if c.Default {
+			t.collectors[c.Name] = true
+		}
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	for name := range t.collectors {
+		if !knownCollector(name) {
+			return nil, fmt.Errorf("unknown collector %q", name)
+		}
+	}
+
+	t.scrapeTimestampDesc = t.newDesc(
+		"", "scrape_timestamp_seconds",
+		"Time when the scrape started.",
+		"",
+		nil,
+	)
+	t.secondsSinceLastSuccessDesc = t.newDesc(
+		"", "seconds_since_last_success",
+		"Seconds since Transmission last successfully answered a request (or since the exporter started).",
+		"",
+		nil,
+	)
+	t.scrapeDurationDesc = t.newDesc(
+		"", "scrape_duration_seconds",
+		"Time it took to collect metrics from Transmission.",
+		"",
+		nil,
+	)
+	t.scrapeSuccessDesc = t.newDesc(
+		"", "scrape_success",
+		"Whether all metrics were successfully collected from Transmission.",
+		"",
+		nil,
+	)
+	t.upDesc = t.newDesc(
+		"", "up",
+		"Whether Transmission answered at least one request during the scrape.",
+		"",
+		nil,
+	)
+	t.scrapeGoroutinesDesc = t.newDesc(
+		"exporter", "scrape_goroutines",
+		"Number of goroutines in the exporter at the end of the scrape.",
+		"",
+		nil,
+	)
+
+	t.versionNumericDesc = t.newDesc(
+		"", "version_numeric",
+		"Transmission version as a comparable number (major + minor/100 + patch/10000).",
+		"session-get version",
+		nil,
+	)
+	t.versionInfoDesc = t.newDesc(
+		"", "version_info",
+		"Transmission version and RPC version, always 1.",
+		"session-get version, rpc-version",
+		[]string{"version", "rpc_version"},
+	)
+
+	t.portOpenDesc = t.newDesc(
+		"", "is_port_open",
+		"Indicates whether or not the peer port is accessible from the internet.",
+		"port-test port-is-open",
+		nil,
+	)
+	t.portClosedSecsDesc = t.newDesc(
+		"", "port_closed_seconds",
+		"Seconds the peer port has been continuously inaccessible from the internet (0 if it is accessible).",
+		"port-test port-is-open",
+		nil,
+	)
+
+	t.turtleModeDesc = t.newDesc(
+		"", "is_turtle_mode_active",
+		"Indicates whether or not turtle mode is active.",
+		"session-get alt-speed-enabled",
+		nil,
+	)
+	t.turtleScheduleDayDesc = t.newDesc(
+		"", "turtle_schedule_day",
+		"Indicates whether or not turtle mode schedule applies on the given day.",
+		"session-get alt-speed-time-day",
+		[]string{"day"},
+	)
+
+	t.activeTorrentsDesc = t.newDesc(
+		"", "active_torrents",
+		"Number of active torrents.",
+		"session-stats activeTorrentCount",
+		nil,
+	)
+	t.pausedTorrentsDesc = t.newDesc(
+		"", "paused_torrents",
+		"Number of paused torrents.",
+		"session-stats pausedTorrentCount",
+		nil,
+	)
+	t.pausedRatioDesc = t.newDesc(
+		"", "paused_ratio",
+		"Ratio of paused torrents to all torrents.",
+		"session-stats pausedTorrentCount, torrentCount",
+		nil,
+	)
+
+	t.downloadedBytesTotalDesc = t.newDesc(
+		"", "downloaded_bytes_total",
+		"Total amount of downloaded data.",
+		"session-stats cumulative-stats.downloadedBytes",
+		nil,
+	)
+	t.uploadedBytesTotalDesc = t.newDesc(
+		"", "uploaded_bytes_total",
+		"Total amount of uploaded data.",
+		"session-stats cumulative-stats.uploadedBytes",
+		nil,
+	)
+
+	t.downloadSpeedDesc = t.newDesc(
+		"", "download_speed_bytes",
+		"Current download speed in bytes per second.",
+		"session-stats downloadSpeed",
+		nil,
+	)
+	t.uploadSpeedDesc = t.newDesc(
+		"", "upload_speed_bytes",
+		"Current upload speed in bytes per second.",
+		"session-stats uploadSpeed",
+		nil,
+	)
+
+	t.currentActiveSecondsDesc = t.newDesc(
+		"", "current_active_seconds",
+		"Time Transmission has been running in the current session.",
+		"session-stats current-stats.secondsActive",
+		nil,
+	)
+	t.sessionCountTotalDesc = t.newDesc(
+		"", "session_count_total",
+		"Total number of times Transmission has been started.",
+		"session-stats cumulative-stats.sessionCount",
+		nil,
+	)
+	t.currentDownloadedBytesDesc = t.newDesc(
+		"", "current_downloaded_bytes",
+		"Amount of data downloaded in the current session.",
+		"session-stats current-stats.downloadedBytes",
+		nil,
+	)
+	t.currentUploadedBytesDesc = t.newDesc(
+		"", "current_uploaded_bytes",
+		"Amount of data uploaded in the current session.",
+		"session-stats current-stats.uploadedBytes",
+		nil,
+	)
+
+	t.uploadLimitUtilizationDesc = t.newDesc(
+		"", "upload_limit_utilization_ratio",
+		"Ratio of current upload speed to the upload limit in effect (NaN if unlimited).",
+		"session-stats uploadSpeed, session-get alt-speed-enabled, alt-speed-up, speed-limit-up, speed-limit-up-enabled",
+		nil,
+	)
+
+	t.speedLimitDownDesc = t.newDesc(
+		"", "speed_limit_down_bytes",
+		"Configured session download speed limit in bytes per second.",
+		"session-get speed-limit-down",
+		nil,
+	)
+	t.speedLimitUpDesc = t.newDesc(
+		"", "speed_limit_up_bytes",
+		"Configured session upload speed limit in bytes per second.",
+		"session-get speed-limit-up",
+		nil,
+	)
+	t.speedLimitDownEnabledDesc = t.newDesc(
+		"", "speed_limit_down_enabled",
+		"Whether session download speed limit is enabled.",
+		"session-get speed-limit-down-enabled",
+		nil,
+	)
+	t.speedLimitUpEnabledDesc = t.newDesc(
+		"", "speed_limit_up_enabled",
+		"Whether session upload speed limit is enabled.",
+		"session-get speed-limit-up-enabled",
+		nil,
+	)
+
+	t.downloadQueueSizeDesc = t.newDesc(
+		"", "download_queue_size",
+		"Maximum number of simultaneously downloading torrents.",
+		"session-get download-queue-size",
+		nil,
+	)
+	t.downloadQueueEnabledDesc = t.newDesc(
+		"", "download_queue_enabled",
+		"Whether download queue is enabled.",
+		"session-get download-queue-enabled",
+		nil,
+	)
+	t.seedQueueSizeDesc = t.newDesc(
+		"", "seed_queue_size",
+		"Maximum number of simultaneously seeding torrents.",
+		"session-get seed-queue-size",
+		nil,
+	)
+	t.seedQueueEnabledDesc = t.newDesc(
+		"", "seed_queue_enabled",
+		"Whether seed queue is enabled.",
+		"session-get seed-queue-enabled",
+		nil,
+	)
+
+	t.blocklistSizeDesc = t.newDesc(
+		"", "blocklist_size",
+		"Number of rules in the peer blocklist.",
+		"session-get blocklist-size",
+		nil,
+	)
+	t.blocklistEnabledDesc = t.newDesc(
+		"", "blocklist_enabled",
+		"Whether the peer blocklist is enabled.",
+		"session-get blocklist-enabled",
+		nil,
+	)
+	t.seedRatioLimitDesc = t.newDesc(
+		"", "seed_ratio_limit",
+		"Global seed ratio limit torrents stop seeding at.",
+		"session-get seedRatioLimit",
+		nil,
+	)
+	t.seedRatioLimitedDesc = t.newDesc(
+		"", "seed_ratio_limited",
+		"Whether the global seed ratio limit is enabled.",
+		"session-get seedRatioLimited",
+		nil,
+	)
+
+	t.freeSpaceDesc = t.newDesc(
+		"", "free_space_bytes",
+		"Free space available in the path as seen by Transmission.",
+		"free-space size-bytes",
+		[]string{"path"},
+	)
+
+	t.torrentsByErrorDesc = t.newDesc(
+		"", "torrents_by_error",
+		"Number of torrents by error category.",
+		"torrent-get error",
+		[]string{"category"},
+	)
+	t.torrentsDesc = t.newDesc(
+		"", "torrents",
+		"Number of torrents by status.",
+		"torrent-get status",
+		[]string{"status"},
+	)
+	t.peersConnectedDesc = t.newDesc(
+		"", "peers_connected",
+		"Number of peers connected across all torrents.",
+		"torrent-get peersConnected",
+		nil,
+	)
+	t.peersSendingDesc = t.newDesc(
+		"", "peers_sending",
+		"Number of peers sending data to us across all torrents.",
+		"torrent-get peersSendingToUs",
+		nil,
+	)
+	t.peersReceivingDesc = t.newDesc(
+		"", "peers_receiving",
+		"Number of peers receiving data from us across all torrents.",
+		"torrent-get peersGettingFromUs",
+		nil,
+	)
+	t.peerLimitUtilizationDesc = t.newDesc(
+		"", "peer_limit_utilization_ratio",
+		"Ratio of peers connected across all torrents to the global peer limit.",
+		"torrent-get peersConnected, session-get peer-limit-global",
+		nil,
+	)
+	t.torrentsQueuedCheckDesc = t.newDesc(
+		"", "torrents_queued_check",
+		"Number of torrents queued to verify local data.",
+		"torrent-get status",
+		nil,
+	)
+	t.torrentsCheckingDesc = t.newDesc(
+		"", "torrents_checking",
+		"Number of torrents verifying local data.",
+		"torrent-get status",
+		nil,
+	)
+	t.torrentsDownloadWaitDesc = t.newDesc(
+		"", "torrents_download_wait",
+		"Number of torrents queued to download.",
+		"torrent-get status",
+		nil,
+	)
+	t.torrentsSeedWaitDesc = t.newDesc(
+		"", "torrents_seed_wait",
+		"Number of torrents queued to seed.",
+		"torrent-get status",
+		nil,
+	)
+	t.torrentsStatusChangedDesc = t.newDesc(
+		"", "torrents_status_changed",
+		"Number of torrents whose status changed since the previous scrape.",
+		"torrent-get status",
+		nil,
+	)
+	t.torrentsByPriorityDesc = t.newDesc(
+		"", "torrents_by_priority",
+		"Number of torrents by bandwidth priority.",
+		"torrent-get bandwidthPriority",
+		[]string{"priority"},
+	)
+	t.torrentsBySeedRatioModeDesc = t.newDesc(
+		"", "torrents_by_seed_ratio_mode",
+		"Number of torrents by seed ratio mode.",
+		"torrent-get seedRatioMode",
+		[]string{"mode"},
+	)
+	t.torrentsByDirDesc = t.newDesc(
+		"", "torrents_by_dir",
+		"Number of torrents by download directory.",
+		"torrent-get downloadDir",
+		[]string{"dir"},
+	)
+	t.averageAvailabilityRatioDesc = t.newDesc(
+		"", "average_availability_ratio",
+		"Ratio of wanted data available from connected peers to data left to download across downloading torrents.",
+		"torrent-get desiredAvailable, leftUntilDone",
+		nil,
+	)
+	t.torrentsIncompleteOldDesc = t.newDesc(
+		"", "torrents_incomplete_old",
+		"Number of incomplete torrents added longer ago than the configured threshold.",
+		"torrent-get percentDone, addedDate",
+		nil,
+	)
+	t.latestTorrentAddedDesc = t.newDesc(
+		"", "latest_torrent_added_timestamp_seconds",
+		"Time when the most recent torrent was added.",
+		"torrent-get addedDate",
+		nil,
+	)
+	t.totalLeftBytesDesc = t.newDesc(
+		"", "total_left_bytes",
+		"Amount of data left to download across downloading torrents.",
+		"torrent-get leftUntilDone",
+		nil,
+	)
+	t.sufficientSpaceDesc = t.newDesc(
+		"", "download_dir_sufficient_space",
+		"Indicates whether or not free space in the download directory is enough to complete its torrents.",
+		"torrent-get downloadDir, leftUntilDone and free-space size-bytes",
+		[]string{"dir"},
+	)
+	t.labelDownloadRateDesc = t.newDesc(
+		"label", "download_rate_bytes",
+		"Total download speed of torrents with the label in bytes per second.",
+		"torrent-get labels, rateDownload",
+		[]string{"label"},
+	)
+	t.labelUploadRateDesc = t.newDesc(
+		"label", "upload_rate_bytes",
+		"Total upload speed of torrents with the label in bytes per second.",
+		"torrent-get labels, rateUpload",
+		[]string{"label"},
+	)
+	t.downloadQueueSlotsFreeDesc = t.newDesc(
+		"", "download_queue_slots_free",
+		"Number of download queue slots not taken by downloading torrents. Only reported when download queue is enabled.",
+		"session-get download-queue-size and torrent-get status",
+		nil,
+	)
+
+	t.distinctTrackersDesc = t.newDesc(
+		"", "distinct_trackers",
+		"Number of distinct tracker hosts across all torrents.",
+		"torrent-get trackerStats.announce",
+		nil,
+	)
+	t.trackersAnnouncingDesc = t.newDesc(
+		"", "trackers_announcing",
+		"Number of trackers currently being announced to or scraped across all torrents.",
+		"torrent-get trackerStats.announceState, trackerStats.scrapeState",
+		nil,
+	)
+	t.trackerDownloadedCountDesc = t.newDesc(
+		"tracker", "downloaded_count",
+		"Number of times torrents were fully downloaded as reported by the tracker.",
+		"torrent-get trackerStats.downloadCount",
+		[]string{"tracker_host"},
+	)
+	t.trackerDownSecondsDesc = t.newDesc(
+		"tracker", "down_seconds",
+		"Seconds announces to the tracker have been failing for all torrents using it (0 if any succeeds).",
+		"torrent-get trackerStats.lastAnnounceSucceeded, trackerStats.lastAnnounceResult",
+		[]string{"tracker_host"},
+	)
+	t.trackerSeederCountDesc = t.newDesc(
+		"tracker", "seeder_count",
+		"Number of seeders of torrent as reported by the tracker.",
+		"torrent-get trackerStats.seederCount",
+		[]string{"tracker", "hash"},
+	)
+	t.trackerLeecherCountDesc = t.newDesc(
+		"tracker", "leecher_count",
+		"Number of leechers of torrent as reported by the tracker.",
+		"torrent-get trackerStats.leecherCount",
+		[]string{"tracker", "hash"},
+	)
+	t.torrentAnnouncePeerCountDesc = t.newDesc(
+		"torrent", "last_announce_peer_count",
+		"Number of peers returned by the tracker at the last announce.",
+		"torrent-get trackerStats.lastAnnouncePeerCount",
+		[]string{"hash", "tracker_host"},
+	)
+	t.trackerAnnounceErrorsDesc = t.newDesc(
+		"tracker", "announce_errors",
+		"Number of trackers across all torrents whose last announce failed.",
+		"torrent-get trackerStats.lastAnnounceSucceeded, trackerStats.lastAnnounceResult",
+		nil,
+	)
+
+	t.torrentProgressRateDesc = t.newDesc(
+		"torrent", "progress_rate_per_second",
+		"Change of torrent progress ratio per second since the previous scrape.",
+		"torrent-get percentDone",
+		[]string{"hash"},
+	)
+	t.torrentEstimatedETADesc = t.newDesc(
+		"torrent", "estimated_eta_seconds",
+		"Estimated time until torrent completes based on its progress rate since the previous scrape.",
+		"torrent-get percentDone",
+		[]string{"hash"},
+	)
+	t.torrentInIncompleteDirDesc = t.newDesc(
+		"torrent", "in_incomplete_dir",
+		"Indicates whether or not torrent data currently lives in the incomplete directory.",
+		"session-get incomplete-dir and torrent-get downloadDir",
+		[]string{"hash"},
+	)
+	t.torrentDownloadEfficiencyDesc = t.newDesc(
+		"torrent", "download_efficiency",
+		"Ratio of current torrent download rate to its recent peak download rate.",
+		"torrent-get rateDownload",
+		[]string{"hash"},
+	)
+	t.torrentSeedingStoppedDesc = t.newDesc(
+		"torrent", "seeding_stopped_timestamp_seconds",
+		"Time when torrent stopped seeding after reaching its seeding goal.",
+		"torrent-get status, isFinished",
+		[]string{"hash"},
+	)
+	t.torrentTrackerWarningsDesc = t.newDesc(
+		"torrent", "tracker_warnings",
+		"Number of torrent trackers whose last announce returned a warning.",
+		"torrent-get trackerStats.lastAnnounceResult",
+		[]string{"hash"},
+	)
+	t.torrentSeedPeerRatioDesc = t.newDesc(
+		"torrent", "seed_peer_ratio",
+		"Ratio of seeders to all peers in torrent swarm as reported by the tracker with the largest swarm.",
+		"torrent-get trackerStats.seederCount, trackerStats.leecherCount",
+		[]string{"hash"},
+	)
+	t.torrentWebseedCountDesc = t.newDesc(
+		"torrent", "webseed_count",
+		"Number of webseeds configured for torrent.",
+		"torrent-get webseeds",
+		[]string{"hash"},
+	)
+	t.torrentActiveTrackersDesc = t.newDesc(
+		"torrent", "active_trackers",
+		"Number of torrent trackers whose last announce succeeded.",
+		"torrent-get trackerStats.lastAnnounceSucceeded",
+		[]string{"hash"},
+	)
+	t.torrentTotalTrackersDesc = t.newDesc(
+		"torrent", "total_trackers",
+		"Number of trackers configured for torrent.",
+		"torrent-get trackerStats",
+		[]string{"hash"},
+	)
+	t.torrentDownloadLimitDesc = t.newDesc(
+		"torrent", "download_limit_configured_bytes",
+		"Configured torrent download speed limit in bytes per second, regardless of whether it is enabled.",
+		"torrent-get downloadLimit",
+		[]string{"hash"},
+	)
+	t.torrentDownloadLimitedDesc = t.newDesc(
+		"torrent", "download_limited",
+		"Indicates whether or not torrent download speed limit is enabled.",
+		"torrent-get downloadLimited",
+		[]string{"hash"},
+	)
+	t.torrentWantedCoverageDesc = t.newDesc(
+		"torrent", "wanted_coverage_ratio",
+		"Ratio of wanted torrent data to total torrent size.",
+		"torrent-get sizeWhenDone, totalSize",
+		[]string{"hash"},
+	)
+	t.torrentDistinctPeerIPsDesc = t.newDesc(
+		"torrent", "distinct_peer_ips",
+		"Number of distinct IP addresses of peers connected to torrent.",
+		"torrent-get peers.address",
+		[]string{"hash"},
+	)
+	t.torrentHasMetadataDesc = t.newDesc(
+		"torrent", "has_metadata",
+		"Whether torrent metadata has been fully retrieved.",
+		"torrent-get metadataPercentComplete",
+		[]string{"hash"},
+	)
+	t.torrentProgressRatioDesc = t.newDesc(
+		"torrent", "progress_ratio",
+		"Ratio of torrent wanted data that has been downloaded.",
+		"torrent-get percentDone",
+		[]string{"hash", "name"},
+	)
+	t.torrentDownloadSpeedDesc = t.newDesc(
+		"torrent", "download_speed_bytes",
+		"Current torrent download speed in bytes per second.",
+		"torrent-get rateDownload",
+		[]string{"hash", "name"},
+	)
+	t.torrentUploadSpeedDesc = t.newDesc(
+		"torrent", "upload_speed_bytes",
+		"Current torrent upload speed in bytes per second.",
+		"torrent-get rateUpload",
+		[]string{"hash", "name"},
+	)
+	t.torrentSizeDesc = t.newDesc(
+		"torrent", "size_bytes",
+		"Size of torrent wanted data in bytes.",
+		"torrent-get sizeWhenDone",
+		[]string{"hash", "name"},
+	)
+	t.torrentDownloadedDesc = t.newDesc(
+		"torrent", "downloaded_bytes",
+		"Number of bytes downloaded for torrent since it was added.",
+		"torrent-get downloadedEver",
+		[]string{"hash", "name"},
+	)
+	t.torrentUploadedDesc = t.newDesc(
+		"torrent", "uploaded_bytes",
+		"Number of bytes uploaded for torrent since it was added.",
+		"torrent-get uploadedEver",
+		[]string{"hash", "name"},
+	)
+	t.torrentActiveFileInfoDesc = t.newDesc(
+		"torrent", "active_file_info",
+		"File Transmission is currently downloading, the first unfinished wanted file of the highest priority.",
+		"torrent-get files, fileStats",
+		[]string{"hash", "file"},
+	)
+	t.torrentVerifiedPiecesDesc = t.newDesc(
+		"torrent", "verified_pieces",
+		"Number of pieces verified so far by the ongoing torrent check.",
+		"torrent-get recheckProgress, pieceCount",
+		[]string{"hash"},
+	)
+	t.torrentSecondsToRatioGoalDesc = t.newDesc(
+		"torrent", "seconds_to_ratio_goal",
+		"Estimated time until torrent reaches its seed ratio limit at the current upload rate (NaN if not uploading).",
+		"torrent-get uploadedEver, downloadedEver, sizeWhenDone, seedRatioMode, seedRatioLimit, session-get seedRatioLimit, seedRatioLimited",
+		[]string{"hash"},
+	)
+	t.torrentEffectiveRatioDesc = t.newDesc(
+		"torrent", "effective_seed_ratio_limit",
+		"Seed ratio limit in effect for torrent, either its own or the global one (absent if unlimited).",
+		"torrent-get seedRatioMode, seedRatioLimit, session-get seedRatioLimit, seedRatioLimited",
+		[]string{"hash"},
+	)
+	t.torrentDirFreeSpaceDesc = t.newDesc(
+		"torrent", "dir_free_space_bytes",
+		"Free space available in torrent download directory as seen by Transmission.",
+		"torrent-get downloadDir, free-space size-bytes",
+		[]string{"hash", "dir"},
+	)
+	t.torrentRatioDesc = t.newDesc(
+		"torrent", "ratio",
+		"Upload ratio of torrent (+Inf if uploaded without downloading anything).",
+		"torrent-get uploadRatio",
+		[]string{"hash"},
+	)
+	t.torrentETADesc = t.newDesc(
+		"torrent", "eta_seconds",
+		"Time until torrent completes as estimated by Transmission (-1 if unknown).",
+		"torrent-get eta",
+		[]string{"hash"},
+	)
+	t.torrentSinceActiveDesc = t.newDesc(
+		"torrent", "seconds_since_active",
+		"Time since torrent last transferred data (absent if it never did).",
+		"torrent-get activityDate",
+		[]string{"hash"},
+	)
+	t.torrentDownloadingPeersDesc = t.newDesc(
+		"torrent", "downloading_peers",
+		"Number of connected peers torrent is downloading from, as opposed to all connected peers.",
+		"torrent-get peersSendingToUs",
+		[]string{"hash"},
+	)
+	t.torrentErrorDesc = t.newDesc(
+		"torrent", "error",
+		"Torrent error category, 1 for the current one.",
+		"torrent-get error",
+		[]string{"hash", "error"},
+	)
+
+	if t.stateless {
+		for _, desc := range t.statefulDescs() {
+			t.denied[desc] = true
+		}
+	}
+
+	if !t.supportsRPC(rpcVersionQueue) {
+		level.Info(logger).Log("msg", "Download queue metrics are not supported by Transmission, disabling", "rpc_version", t.rpcVersion)
+	}
+	if !t.supportsRPC(rpcVersionLabels) {
+		level.Info(logger).Log("msg", "Label metrics are not supported by Transmission, disabling", "rpc_version", t.rpcVersion)
+	}
+
+	return t, nil
+}
+
+// statefulDescs returns descriptors of metrics that are derived from previous scrapes,
+// and of the peer port check, which asks an external service on every call.
+func (t *TransmissionCollector) statefulDescs() []*prometheus.Desc {
+	return []*prometheus.Desc{
+		t.secondsSinceLastSuccessDesc,
+		t.portOpenDesc,
+		t.portClosedSecsDesc,
+		t.torrentProgressRateDesc,
+		t.torrentEstimatedETADesc,
+		t.torrentDownloadEfficiencyDesc,
+		t.torrentSeedingStoppedDesc,
+		t.torrentSecondsToRatioGoalDesc,
+		t.trackerDownSecondsDesc,
+		t.torrentsStatusChangedDesc,
+	}
+}
+
+// supportsRPC reports whether Transmission is known or assumed to speak at least the given RPC version.
+func (t *TransmissionCollector) supportsRPC(version int) bool {
+	return t.rpcVersion == 0 || t.rpcVersion >= version
+}
+
+// newDesc creates a metric descriptor, marking it as denied if its name matches the denylist
+// or if it is a per-torrent metric and only aggregates are requested. source names
+// Transmission RPC method and fields the metric is derived from.
+func (t *TransmissionCollector) newDesc(subsystem, name, help, source string, variableLabels []string) *prometheus.Desc {
+	if t.verboseHelp && source != "" {
+		help = strings.TrimSuffix(help, ".") + " (from " + source + ")."
+	}
+
+	fqName := prometheus.BuildFQName(namespace, subsystem, name)
+	desc := prometheus.NewDesc(fqName, help, variableLabels, nil)
+	if t.allowlist != nil && !t.allowlist[fqName] {
+		t.denied[desc] = true
+	}
+	if t.aggregatesOnly && containsString(variableLabels, "hash") {
+		t.denied[desc] = true
+	}
+	for _, re := range t.denylist {
+		if re.MatchString(fqName) {
+			t.denied[desc] = true
+			break
+		}
+	}
+
+	return desc
+}
+
+// exposed reports whether any of descs is allowed to be emitted.
+func (t *TransmissionCollector) exposed(descs ...*prometheus.Desc) bool {
+	for _, desc := range descs {
+		if !t.denied[desc] {
+			return true
+		}
+	}
+
+	return false
 }
 
 // Describe implements the prometheus.Collector interface
 func (t *TransmissionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- t.scrapeTimestampDesc
+	ch <- t.secondsSinceLastSuccessDesc
+	ch <- t.scrapeDurationDesc
+	ch <- t.scrapeSuccessDesc
+	ch <- t.upDesc
+	ch <- t.scrapeGoroutinesDesc
+
+	ch <- t.versionNumericDesc
+	ch <- t.versionInfoDesc
+
 	ch <- t.portOpenDesc
+	ch <- t.portClosedSecsDesc
 
 	ch <- t.turtleModeDesc
+	ch <- t.turtleScheduleDayDesc
 
 	ch <- t.activeTorrentsDesc
 	ch <- t.pausedTorrentsDesc
+	ch <- t.pausedRatioDesc
 
 	ch <- t.downloadedBytesTotalDesc
 	ch <- t.uploadedBytesTotalDesc
+	ch <- t.downloadSpeedDesc
+	ch <- t.uploadSpeedDesc
+
+	ch <- t.currentActiveSecondsDesc
+	ch <- t.sessionCountTotalDesc
+	ch <- t.currentDownloadedBytesDesc
+	ch <- t.currentUploadedBytesDesc
+
+	ch <- t.uploadLimitUtilizationDesc
+
+	ch <- t.speedLimitDownDesc
+	ch <- t.speedLimitUpDesc
+	ch <- t.speedLimitDownEnabledDesc
+	ch <- t.speedLimitUpEnabledDesc
+
+	ch <- t.downloadQueueSizeDesc
+	ch <- t.downloadQueueEnabledDesc
+	ch <- t.seedQueueSizeDesc
+	ch <- t.seedQueueEnabledDesc
+
+	ch <- t.blocklistSizeDesc
+	ch <- t.blocklistEnabledDesc
+	ch <- t.seedRatioLimitDesc
+	ch <- t.seedRatioLimitedDesc
+
+	ch <- t.freeSpaceDesc
+
+	ch <- t.torrentsByErrorDesc
+	ch <- t.torrentsDesc
+	ch <- t.peersConnectedDesc
+	ch <- t.peersSendingDesc
+	ch <- t.peersReceivingDesc
+	ch <- t.peerLimitUtilizationDesc
+	ch <- t.torrentsQueuedCheckDesc
+	ch <- t.torrentsCheckingDesc
+	ch <- t.torrentsDownloadWaitDesc
+	ch <- t.torrentsSeedWaitDesc
+	ch <- t.torrentsStatusChangedDesc
+	ch <- t.torrentsByPriorityDesc
+	ch <- t.torrentsBySeedRatioModeDesc
+	ch <- t.torrentsByDirDesc
+	ch <- t.averageAvailabilityRatioDesc
+	ch <- t.torrentsIncompleteOldDesc
+	ch <- t.latestTorrentAddedDesc
+	ch <- t.totalLeftBytesDesc
+	ch <- t.sufficientSpaceDesc
+	ch <- t.labelDownloadRateDesc
+	ch <- t.labelUploadRateDesc
+	ch <- t.downloadQueueSlotsFreeDesc
+
+	ch <- t.distinctTrackersDesc
+	ch <- t.trackersAnnouncingDesc
+	ch <- t.trackerDownloadedCountDesc
+	ch <- t.trackerDownSecondsDesc
+	ch <- t.trackerSeederCountDesc
+	ch <- t.trackerLeecherCountDesc
+	ch <- t.torrentAnnouncePeerCountDesc
+	ch <- t.trackerAnnounceErrorsDesc
+
+	ch <- t.torrentProgressRateDesc
+	ch <- t.torrentEstimatedETADesc
+	ch <- t.torrentInIncompleteDirDesc
+	ch <- t.torrentDownloadEfficiencyDesc
+	ch <- t.torrentSeedingStoppedDesc
+	ch <- t.torrentTrackerWarningsDesc
+	ch <- t.torrentSeedPeerRatioDesc
+	ch <- t.torrentWebseedCountDesc
+	ch <- t.torrentActiveTrackersDesc
+	ch <- t.torrentTotalTrackersDesc
+	ch <- t.torrentDownloadLimitDesc
+	ch <- t.torrentDownloadLimitedDesc
+	ch <- t.torrentWantedCoverageDesc
+	ch <- t.torrentDistinctPeerIPsDesc
+	ch <- t.torrentHasMetadataDesc
+	ch <- t.torrentProgressRatioDesc
+	ch <- t.torrentDownloadSpeedDesc
+	ch <- t.torrentUploadSpeedDesc
+	ch <- t.torrentSizeDesc
+	ch <- t.torrentDownloadedDesc
+	ch <- t.torrentUploadedDesc
+	ch <- t.torrentActiveFileInfoDesc
+	ch <- t.torrentVerifiedPiecesDesc
+	ch <- t.torrentSecondsToRatioGoalDesc
+	ch <- t.torrentEffectiveRatioDesc
+	ch <- t.torrentErrorDesc
+	ch <- t.torrentETADesc
+	ch <- t.torrentSinceActiveDesc
+	ch <- t.torrentDownloadingPeersDesc
+	ch <- t.torrentDirFreeSpaceDesc
+	ch <- t.torrentRatioDesc
 }
 
 // Collect implements the prometheus.Collector interface.
 func (t *TransmissionCollector) Collect(ch chan<- prometheus.Metric) {
-	fns := []func(chan<- prometheus.Metric){
-		t.collectPortOpen,
-		t.collectTurtleMode,
-		t.collectSessionStats,
+	if len(t.denied) > 0 {
+		filtered := make(chan prometheus.Metric)
+		done := make(chan struct{})
+		go func(out chan<- prometheus.Metric) {
+			for m := range filtered {
+				if !t.denied[m.Desc()] {
+					out <- m
+				}
+			}
+			close(done)
+		}(ch)
+		defer func() {
+			close(filtered)
+			<-done
+		}()
+		ch = filtered
+	}
+
+	start := time.Now()
+	ch <- prometheus.MustNewConstMetric(t.scrapeTimestampDesc, prometheus.GaugeValue, float64(start.UnixNano())/1e9)
+
+	// Session statistics are shared by two collectors, request them once per scrape.
+	stats := t.newSessionStatsFunc()
+	collectSessionStats := func(ch chan<- prometheus.Metric, _ sessionFunc, _ freeSpaceFunc) error {
+		return t.collectSessionStats(ch, stats)
+	}
+	collectUploadLimitUtilization := func(ch chan<- prometheus.Metric, session sessionFunc, _ freeSpaceFunc) error {
+		return t.collectUploadLimitUtilization(ch, session, stats)
+	}
+
+	// Skip Transmission requests if none of the resulting metrics would be exposed.
+	var fns []func(chan<- prometheus.Metric, sessionFunc, freeSpaceFunc) error
+	add := func(name string, fn func(chan<- prometheus.Metric, sessionFunc, freeSpaceFunc) error, descs ...*prometheus.Desc) {
+		if t.collectors[name] && t.exposed(descs...) {
+			fns = append(fns, fn)
+		}
+	}
+	add("version", t.collectVersion, t.versionNumericDesc, t.versionInfoDesc)
+	add("port", t.collectPortOpen, t.portOpenDesc, t.portClosedSecsDesc)
+	add("turtle", t.collectTurtleMode, t.turtleModeDesc, t.turtleScheduleDayDesc)
+	add("stats", collectSessionStats,
+		t.activeTorrentsDesc, t.pausedTorrentsDesc, t.pausedRatioDesc,
+		t.downloadedBytesTotalDesc, t.uploadedBytesTotalDesc,
+		t.downloadSpeedDesc, t.uploadSpeedDesc,
+		t.currentActiveSecondsDesc, t.sessionCountTotalDesc,
+		t.currentDownloadedBytesDesc, t.currentUploadedBytesDesc,
+	)
+	add("speed-limits", collectUploadLimitUtilization, t.uploadLimitUtilizationDesc)
+	add("speed-limits", t.collectSpeedLimits,
+		t.speedLimitDownDesc, t.speedLimitUpDesc,
+		t.speedLimitDownEnabledDesc, t.speedLimitUpEnabledDesc,
+	)
+	if t.supportsRPC(rpcVersionQueue) {
+		add("queue", t.collectQueue,
+			t.downloadQueueSizeDesc, t.downloadQueueEnabledDesc,
+			t.seedQueueSizeDesc, t.seedQueueEnabledDesc,
+		)
+	}
+	add("blocklist", t.collectBlocklist, t.blocklistSizeDesc, t.blocklistEnabledDesc)
+	add("seed-ratio", t.collectSeedRatio, t.seedRatioLimitDesc, t.seedRatioLimitedDesc)
+	add("free-space", t.collectFreeSpace, t.freeSpaceDesc)
+	if t.preset >= PresetStandard {
+		add("status", t.collectTorrentStatus, t.torrentsDesc)
+		add("peers", t.collectPeers, t.peersConnectedDesc, t.peersSendingDesc, t.peersReceivingDesc, t.peerLimitUtilizationDesc)
+		add("torrents", t.collectTorrents, t.torrentDescs()...)
+	}
+	if t.preset >= PresetFull {
+		add("trackers", t.collectTrackerStats,
+			t.trackerSeederCountDesc, t.trackerLeecherCountDesc,
+			t.torrentAnnouncePeerCountDesc, t.trackerAnnounceErrorsDesc,
+		)
 	}
 
+	session := t.newSessionFunc()
+	freeSpace := t.newFreeSpaceFunc()
 	var wg sync.WaitGroup
+	errs := make(chan error, len(fns))
 
 	wg.Add(len(fns))
 	for _, fn := range fns {
 		fn := fn
 		go func() {
-			fn(ch)
+			errs <- fn(ch, session, freeSpace)
 			wg.Done()
 		}()
 	}
 
 	wg.Wait()
+	close(errs)
+
+	ch <- prometheus.MustNewConstMetric(t.scrapeDurationDesc, prometheus.GaugeValue, time.Since(start).Seconds())
+	success := 1.
+	for err := range errs {
+		if err != nil {
+			success = 0.
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(t.scrapeSuccessDesc, prometheus.GaugeValue, success)
+
+	t.lastSuccessMu.Lock()
+	lastSuccess := t.lastSuccess
+	t.lastSuccessMu.Unlock()
+	ch <- prometheus.MustNewConstMetric(t.secondsSinceLastSuccessDesc, prometheus.GaugeValue, time.Since(lastSuccess).Seconds())
+
+	up := 0.
+	if !lastSuccess.Before(start) {
+		up = 1.
+	}
+	ch <- prometheus.MustNewConstMetric(t.upDesc, prometheus.GaugeValue, up)
+
+	ch <- prometheus.MustNewConstMetric(t.scrapeGoroutinesDesc, prometheus.GaugeValue, float64(runtime.NumGoroutine()))
+}
+
+// sessionFields lists session fields used by all collectors, so that session is requested
+// only once per scrape.
+var sessionFields = []transmission.SessionField{
+	transmission.SessionFieldVersion,
+	transmission.SessionFieldRPCVersion,
+	transmission.SessionFieldTurtleEnabled,
+	transmission.SessionFieldTurtleScheduleOnDays,
+	transmission.SessionFieldTurtleUploadRateLimit,
+	transmission.SessionFieldDownloadRateLimit,
+	transmission.SessionFieldDownloadRateLimitEnabled,
+	transmission.SessionFieldUploadRateLimit,
+	transmission.SessionFieldUploadRateLimitEnabled,
+	transmission.SessionFieldBlocklistSize,
+	transmission.SessionFieldBlocklistEnabled,
+	transmission.SessionFieldDownloadDirectory,
+	transmission.SessionFieldIncompleteDirectory,
+	transmission.SessionFieldIncompleteDirectoryEnabled,
+	transmission.SessionFieldUploadRatio,
+	transmission.SessionFieldUploadRatioEnabled,
+	transmission.SessionFieldGlobalPeerLimit,
+}
+
+// sessionQueueFields lists session fields only known to daemons supporting queues.
+var sessionQueueFields = []transmission.SessionField{
+	transmission.SessionFieldDownloadQueueLimit,
+	transmission.SessionFieldDownloadQueueLimitEnabled,
+	transmission.SessionFieldUploadQueueLimit,
+	transmission.SessionFieldUploadQueueLimitEnabled,
+}
+
+// sessionFunc returns Transmission session shared by collectors within a scrape.
+type sessionFunc func() (*transmission.Session, error)
+
+// newSessionFunc returns sessionFunc that requests the session on first use.
+func (t *TransmissionCollector) newSessionFunc() sessionFunc {
+	var (
+		once sync.Once
+		sess *transmission.Session
+		err  error
+	)
+
+	fields := sessionFields
+	if t.supportsRPC(rpcVersionQueue) {
+		fields = append(fields[:len(fields):len(fields)], sessionQueueFields...)
+	}
+
+	return func() (*transmission.Session, error) {
+		once.Do(func() {
+			ctx, cancel := t.newContext()
+			defer cancel()
+
+			sess, err = t.client.GetSession(ctx, fields...)
+			if err == nil {
+				t.markSuccess()
+			}
+		})
+
+		return sess, err
+	}
+}
+
+// sessionStatsFunc returns Transmission session statistics shared by collectors within a scrape.
+type sessionStatsFunc func() (*transmission.SessionStats, error)
+
+// newSessionStatsFunc returns sessionStatsFunc that requests the statistics on first use.
+func (t *TransmissionCollector) newSessionStatsFunc() sessionStatsFunc {
+	var (
+		once  sync.Once
+		stats *transmission.SessionStats
+		err   error
+	)
+
+	return func() (*transmission.SessionStats, error) {
+		once.Do(func() {
+			ctx, cancel := t.newContext()
+			defer cancel()
+
+			stats, err = t.client.GetSessionStats(ctx)
+			if err == nil {
+				t.markSuccess()
+			}
+		})
+
+		return stats, err
+	}
 }
 
-func (t *TransmissionCollector) collectPortOpen(ch chan<- prometheus.Metric) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
-	defer cancel()
+// freeSpaceFunc returns free space in path shared by collectors within a scrape.
+type freeSpaceFunc func(path string) (int64, error)
+
+// newFreeSpaceFunc returns freeSpaceFunc that requests free space of each path on first use.
+func (t *TransmissionCollector) newFreeSpaceFunc() freeSpaceFunc {
+	type result struct {
+		once sync.Once
+		free int64
+		err  error
+	}
+
+	var mu sync.Mutex
+	results := make(map[string]*result)
+
+	return func(path string) (int64, error) {
+		mu.Lock()
+		r, ok := results[path]
+		if !ok {
+			r = new(result)
+			results[path] = r
+		}
+		mu.Unlock()
+
+		r.once.Do(func() {
+			ctx, cancel := t.newContext()
+			defer cancel()
+
+			r.free, r.err = t.client.GetFreeSpace(ctx, path)
+			if r.err == nil {
+				t.markSuccess()
+			}
+		})
+
+		return r.free, r.err
+	}
+}
 
-	open, err := t.client.IsPortOpen(ctx)
+// newContext returns a context for Transmission RPC requests bounded by the configured timeout.
+func (t *TransmissionCollector) newContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), t.timeout)
+}
+
+// markSuccess records that Transmission has successfully answered a request.
+func (t *TransmissionCollector) markSuccess() {
+	t.lastSuccessMu.Lock()
+	t.lastSuccess = time.Now()
+	t.lastSuccessMu.Unlock()
+}
+
+func (t *TransmissionCollector) collectVersion(ch chan<- prometheus.Metric, session sessionFunc, _ freeSpaceFunc) error {
+	sess, err := session()
 	if err != nil {
-		level.Warn(t.logger).Log("msg", "failed to get peer port state, considering it closed", "err", err)
-		open = false
+		ch <- prometheus.NewInvalidMetric(t.versionNumericDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.versionInfoDesc, err)
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(t.versionInfoDesc, prometheus.GaugeValue, 1, sess.Version, strconv.Itoa(sess.RPCVersion))
+
+	version, err := parseVersion(sess.Version)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(t.versionNumericDesc, err)
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(t.versionNumericDesc, prometheus.GaugeValue, version)
+
+	return nil
+}
+
+// parseVersion converts Transmission version string (e.g. "4.0.5 (a6fe2a64aa)") into
+// a number that sorts the same way as versions do.
+func parseVersion(version string) (float64, error) {
+	fields := strings.Fields(version)
+	if len(fields) == 0 {
+		return 0, errors.New("empty version")
+	}
+
+	release := strings.SplitN(fields[0], "-", 2)[0]
+	var val float64
+	for i, part := range strings.SplitN(release, ".", 3) {
+		n, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid version %q: %s", version, err)
+		}
+		val += float64(n) / math.Pow(100, float64(i))
+	}
+
+	return val, nil
+}
+
+func (t *TransmissionCollector) collectPortOpen(ch chan<- prometheus.Metric, _ sessionFunc, _ freeSpaceFunc) error {
+	t.portMu.Lock()
+	fresh := !t.portCheckedAt.IsZero() && time.Since(t.portCheckedAt) < t.portCheckInterval
+	open := t.portOpen
+	t.portMu.Unlock()
+
+	if !fresh {
+		ctx, cancel := t.newContext()
+		defer cancel()
+
+		var err error
+		open, err = t.client.IsPortOpen(ctx)
+		if err != nil {
+			level.Warn(t.logger).Log("msg", "failed to get peer port state, considering it closed", "err", err)
+			open = false
+		} else {
+			t.markSuccess()
+		}
+
+		t.portMu.Lock()
+		t.portOpen = open
+		// Failed checks are retried on the next scrape.
+		if err == nil {
+			t.portCheckedAt = time.Now()
+		}
+		t.portMu.Unlock()
 	}
 
 	val := 0.
@@ -123,13 +1532,30 @@ func (t *TransmissionCollector) collectPortOpen(ch chan<- prometheus.Metric) {
 	}
 
 	ch <- prometheus.MustNewConstMetric(t.portOpenDesc, prometheus.GaugeValue, val)
+
+	t.portMu.Lock()
+	closedFor := 0.
+	switch {
+	case open:
+		t.portClosedSince = time.Time{}
+	case t.portClosedSince.IsZero():
+		t.portClosedSince = time.Now()
+	default:
+		closedFor = time.Since(t.portClosedSince).Seconds()
+	}
+	t.portMu.Unlock()
+	ch <- prometheus.MustNewConstMetric(t.portClosedSecsDesc, prometheus.GaugeValue, closedFor)
+
+	// Failed port checks are reported as closed port rather than as collection errors.
+	return nil
 }
 
-func (t *TransmissionCollector) collectTurtleMode(ch chan<- prometheus.Metric) {
-	sess, err := t.client.GetSession(context.Background(), transmission.SessionFieldTurtleEnabled)
+func (t *TransmissionCollector) collectTurtleMode(ch chan<- prometheus.Metric, session sessionFunc, _ freeSpaceFunc) error {
+	sess, err := session()
 	if err != nil {
 		ch <- prometheus.NewInvalidMetric(t.turtleModeDesc, err)
-		return
+		ch <- prometheus.NewInvalidMetric(t.turtleScheduleDayDesc, err)
+		return err
 	}
 
 	val := 0.
@@ -137,20 +1563,214 @@ func (t *TransmissionCollector) collectTurtleMode(ch chan<- prometheus.Metric) {
 		val = 1.
 	}
 	ch <- prometheus.MustNewConstMetric(t.turtleModeDesc, prometheus.GaugeValue, val)
+
+	for day, name := range weekdays {
+		val := 0.
+		if sess.TurtleScheduleOnDays&day != 0 {
+			val = 1.
+		}
+		ch <- prometheus.MustNewConstMetric(t.turtleScheduleDayDesc, prometheus.GaugeValue, val, name)
+	}
+
+	return nil
 }
 
-func (t *TransmissionCollector) collectSessionStats(ch chan<- prometheus.Metric) {
-	stats, err := t.client.GetSessionStats(context.Background())
+func (t *TransmissionCollector) collectSessionStats(ch chan<- prometheus.Metric, sessionStats sessionStatsFunc) error {
+	stats, err := sessionStats()
 	if err != nil {
 		ch <- prometheus.NewInvalidMetric(t.activeTorrentsDesc, err)
 		ch <- prometheus.NewInvalidMetric(t.pausedTorrentsDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.pausedRatioDesc, err)
 		ch <- prometheus.NewInvalidMetric(t.downloadedBytesTotalDesc, err)
 		ch <- prometheus.NewInvalidMetric(t.uploadedBytesTotalDesc, err)
-		return
+		ch <- prometheus.NewInvalidMetric(t.downloadSpeedDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.uploadSpeedDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.currentActiveSecondsDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.sessionCountTotalDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.currentDownloadedBytesDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.currentUploadedBytesDesc, err)
+		return err
 	}
 
 	ch <- prometheus.MustNewConstMetric(t.activeTorrentsDesc, prometheus.GaugeValue, float64(stats.ActiveTorrents))
 	ch <- prometheus.MustNewConstMetric(t.pausedTorrentsDesc, prometheus.GaugeValue, float64(stats.PausedTorrents))
+	pausedRatio := 0.
+	if stats.Torrents > 0 {
+		pausedRatio = float64(stats.PausedTorrents) / float64(stats.Torrents)
+	}
+	ch <- prometheus.MustNewConstMetric(t.pausedRatioDesc, prometheus.GaugeValue, pausedRatio)
 	ch <- prometheus.MustNewConstMetric(t.downloadedBytesTotalDesc, prometheus.GaugeValue, float64(stats.AllSessions.Downloaded))
 	ch <- prometheus.MustNewConstMetric(t.uploadedBytesTotalDesc, prometheus.GaugeValue, float64(stats.AllSessions.Uploaded))
+	ch <- prometheus.MustNewConstMetric(t.downloadSpeedDesc, prometheus.GaugeValue, float64(stats.DownloadRate))
+	ch <- prometheus.MustNewConstMetric(t.uploadSpeedDesc, prometheus.GaugeValue, float64(stats.UploadRate))
+
+	ch <- prometheus.MustNewConstMetric(t.currentActiveSecondsDesc, prometheus.GaugeValue, stats.CurrentSession.ActiveFor.Seconds())
+	ch <- prometheus.MustNewConstMetric(t.sessionCountTotalDesc, prometheus.GaugeValue, float64(stats.AllSessions.Sessions))
+	ch <- prometheus.MustNewConstMetric(t.currentDownloadedBytesDesc, prometheus.GaugeValue, float64(stats.CurrentSession.Downloaded))
+	ch <- prometheus.MustNewConstMetric(t.currentUploadedBytesDesc, prometheus.GaugeValue, float64(stats.CurrentSession.Uploaded))
+
+	return nil
+}
+
+func (t *TransmissionCollector) collectUploadLimitUtilization(ch chan<- prometheus.Metric, session sessionFunc, sessionStats sessionStatsFunc) error {
+	sess, err := session()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(t.uploadLimitUtilizationDesc, err)
+		return err
+	}
+	stats, err := sessionStats()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(t.uploadLimitUtilizationDesc, err)
+		return err
+	}
+
+	// Turtle mode limits override regular ones while active.
+	var limit int64
+	switch {
+	case sess.TurtleEnabled:
+		limit = sess.TurtleUploadRateLimit
+	case sess.UploadRateLimitEnabled:
+		limit = sess.UploadRateLimit
+	}
+
+	ratio := math.NaN()
+	if limit > 0 {
+		ratio = float64(stats.UploadRate) / float64(limit)
+	}
+	ch <- prometheus.MustNewConstMetric(t.uploadLimitUtilizationDesc, prometheus.GaugeValue, ratio)
+
+	return nil
+}
+
+func (t *TransmissionCollector) collectSpeedLimits(ch chan<- prometheus.Metric, session sessionFunc, _ freeSpaceFunc) error {
+	sess, err := session()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(t.speedLimitDownDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.speedLimitUpDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.speedLimitDownEnabledDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.speedLimitUpEnabledDesc, err)
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(t.speedLimitDownDesc, prometheus.GaugeValue, float64(sess.DownloadRateLimit))
+	ch <- prometheus.MustNewConstMetric(t.speedLimitUpDesc, prometheus.GaugeValue, float64(sess.UploadRateLimit))
+	downEnabled := 0.
+	if sess.DownloadRateLimitEnabled {
+		downEnabled = 1.
+	}
+	ch <- prometheus.MustNewConstMetric(t.speedLimitDownEnabledDesc, prometheus.GaugeValue, downEnabled)
+	upEnabled := 0.
+	if sess.UploadRateLimitEnabled {
+		upEnabled = 1.
+	}
+	ch <- prometheus.MustNewConstMetric(t.speedLimitUpEnabledDesc, prometheus.GaugeValue, upEnabled)
+
+	return nil
+}
+
+func (t *TransmissionCollector) collectQueue(ch chan<- prometheus.Metric, session sessionFunc, _ freeSpaceFunc) error {
+	sess, err := session()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(t.downloadQueueSizeDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.downloadQueueEnabledDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.seedQueueSizeDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.seedQueueEnabledDesc, err)
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(t.downloadQueueSizeDesc, prometheus.GaugeValue, float64(sess.DownloadQueueLimit))
+	downloadEnabled := 0.
+	if sess.DownloadQueueLimitEnabled {
+		downloadEnabled = 1.
+	}
+	ch <- prometheus.MustNewConstMetric(t.downloadQueueEnabledDesc, prometheus.GaugeValue, downloadEnabled)
+	ch <- prometheus.MustNewConstMetric(t.seedQueueSizeDesc, prometheus.GaugeValue, float64(sess.UploadQueueLimit))
+	seedEnabled := 0.
+	if sess.UploadQueueLimitEnabled {
+		seedEnabled = 1.
+	}
+	ch <- prometheus.MustNewConstMetric(t.seedQueueEnabledDesc, prometheus.GaugeValue, seedEnabled)
+
+	return nil
+}
+
+func (t *TransmissionCollector) collectBlocklist(ch chan<- prometheus.Metric, session sessionFunc, _ freeSpaceFunc) error {
+	sess, err := session()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(t.blocklistSizeDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.blocklistEnabledDesc, err)
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(t.blocklistSizeDesc, prometheus.GaugeValue, float64(sess.BlocklistSize))
+	enabled := 0.
+	if sess.BlocklistEnabled {
+		enabled = 1.
+	}
+	ch <- prometheus.MustNewConstMetric(t.blocklistEnabledDesc, prometheus.GaugeValue, enabled)
+
+	return nil
+}
+
+func (t *TransmissionCollector) collectSeedRatio(ch chan<- prometheus.Metric, session sessionFunc, _ freeSpaceFunc) error {
+	sess, err := session()
+	if err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(t.seedRatioLimitDesc, prometheus.GaugeValue, sess.UploadRatio)
+	limited := 0.
+	if sess.UploadRatioEnabled {
+		limited = 1.
+	}
+	ch <- prometheus.MustNewConstMetric(t.seedRatioLimitedDesc, prometheus.GaugeValue, limited)
+
+	return nil
+}
+
+func (t *TransmissionCollector) collectFreeSpace(ch chan<- prometheus.Metric, session sessionFunc, freeSpace freeSpaceFunc) error {
+	var lastErr error
+	paths := t.freeSpacePaths
+	sess, err := session()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(t.freeSpaceDesc, err)
+		lastErr = err
+	} else if !containsString(paths, sess.DownloadDirectory) {
+		paths = append([]string{sess.DownloadDirectory}, paths...)
+	}
+
+	for _, path := range paths {
+		free, err := freeSpace(path)
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(t.freeSpaceDesc, err)
+			lastErr = err
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(t.freeSpaceDesc, prometheus.GaugeValue, float64(free), path)
+	}
+
+	return lastErr
+}
+
+// knownCollector reports whether name is one of Collectors.
+func knownCollector(name string) bool {
+	for _, c := range Collectors {
+		if c.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsString reports whether s is in list.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
 }
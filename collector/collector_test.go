@@ -0,0 +1,130 @@
+package collector
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/pborzenkov/go-transmission/transmission"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    float64
+		wantErr bool
+	}{
+		{version: "4.0.5 (a6fe2a64aa)", want: 4.0005},
+		{version: "3.00 (bb6b5a062e)", want: 3},
+		{version: "2.94", want: 2.94},
+		{version: "4.1.0-beta.1 (5a8ef0a8bd)", want: 4.01},
+		{version: "", wantErr: true},
+		{version: "unknown", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := parseVersion(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseVersion(%q) error = %v, want error %v", tt.version, err, tt.wantErr)
+			}
+			if diff := got - tt.want; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("parseVersion(%q) = %v, want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetricFilters(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		methods map[string]bool
+		fields  map[string]bool
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method    string `json:"method"`
+			Arguments struct {
+				Fields []string `json:"fields"`
+			} `json:"arguments"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		methods[req.Method] = true
+		for _, field := range req.Arguments.Fields {
+			fields[field] = true
+		}
+		mu.Unlock()
+
+		if req.Method == "torrent-get" {
+			w.Write([]byte(`{"arguments":{"torrents":[{"hashString":"abc","name":"test","percentDone":0.5,"totalSize":100,"sizeWhenDone":50}]},"result":"success"}`))
+			return
+		}
+		w.Write([]byte(`{"arguments":{},"result":"success"}`))
+	}))
+	defer srv.Close()
+
+	client, err := transmission.New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collect := func(opts ...Option) (*TransmissionCollector, map[*prometheus.Desc]bool) {
+		methods, fields = make(map[string]bool), make(map[string]bool)
+
+		c, err := NewTransmissionCollector(client, log.NewNopLogger(), append(opts, WithCollectors([]string{"stats", "torrents"}), WithTorrentPeers(true))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ch := make(chan prometheus.Metric)
+		go func() {
+			c.Collect(ch)
+			close(ch)
+		}()
+		descs := make(map[*prometheus.Desc]bool)
+		for m := range ch {
+			descs[m.Desc()] = true
+		}
+		return c, descs
+	}
+
+	c, descs := collect()
+	if !descs[c.torrentProgressRatioDesc] || !fields["peers"] {
+		t.Fatalf("unfiltered: per-torrent metrics or their fields are missing")
+	}
+
+	c, descs = collect(WithMetricAllowlist([]string{"transmission_active_torrents"}))
+	if len(descs) != 1 || !descs[c.activeTorrentsDesc] {
+		t.Errorf("allowlist: collected %d metric kinds, want only transmission_active_torrents", len(descs))
+	}
+	if methods["torrent-get"] || methods["session-get"] {
+		t.Errorf("allowlist: requested %v, want only session-stats", methods)
+	}
+
+	c, descs = collect(WithMetricDenylist([]*regexp.Regexp{
+		regexp.MustCompile("^transmission_(torrent|tracker)_"),
+		regexp.MustCompile("^transmission_torrents_status_changed$"),
+	}))
+	if !descs[c.torrentsByDirDesc] {
+		t.Errorf("denylist: aggregated torrent metrics are missing")
+	}
+	for _, desc := range c.torrentDetailDescs() {
+		if descs[desc] {
+			t.Errorf("denylist: collected denied metric %s", desc)
+		}
+	}
+	for _, field := range []string{"hashString", "name", "peers"} {
+		if fields[field] {
+			t.Errorf("denylist: requested torrent field %q only needed by denied metrics", field)
+		}
+	}
+}
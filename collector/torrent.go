@@ -0,0 +1,923 @@
+package collector
+
+import (
+	"math"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/pborzenkov/go-transmission/transmission"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var statuses = map[transmission.Status]string{
+	transmission.StatusStopped:      "stopped",
+	transmission.StatusCheckWait:    "check_wait",
+	transmission.StatusCheck:        "check",
+	transmission.StatusDownloadWait: "download_wait",
+	transmission.StatusDownload:     "downloading",
+	transmission.StatusSeedWait:     "seed_wait",
+	transmission.StatusSeed:         "seeding",
+}
+
+var errorCategories = map[transmission.ErrorType]string{
+	transmission.ErrorTypeOK:             "ok",
+	transmission.ErrorTypeTrackerWarning: "tracker_warning",
+	transmission.ErrorTypeTrackerError:   "tracker_error",
+	transmission.ErrorTypeLocalError:     "local_error",
+}
+
+var priorities = map[transmission.Priority]string{
+	transmission.PriorityLow:    "low",
+	transmission.PriorityNormal: "normal",
+	transmission.PriorityHigh:   "high",
+}
+
+var seedRatioModes = map[transmission.Limit]string{
+	transmission.LimitGlobal:    "global",
+	transmission.LimitLocal:     "single",
+	transmission.LimitUnlimited: "unlimited",
+}
+
+// Transmission RPC versions that introduced data used by the collector.
+const (
+	rpcVersionQueue  = 14 // Transmission 2.40
+	rpcVersionLabels = 16 // Transmission 3.00
+)
+
+// peakRateHalfLife is the time it takes for a recorded peak rate to decay by half.
+const peakRateHalfLife = time.Hour
+
+// torrentState holds per-torrent values derived across scrapes.
+type torrentState struct {
+	updated     time.Time
+	percentDone float64
+
+	hasProgressRate bool
+	progressRate    float64
+
+	peakDownloadRate float64
+
+	uploadedTotal int64
+	hasUploadRate bool
+	uploadRate    float64
+
+	status         transmission.Status
+	seedingStopped time.Time
+}
+
+func (s *torrentState) update(tr *transmission.Torrent, now time.Time) {
+	if !s.updated.IsZero() {
+		if elapsed := now.Sub(s.updated).Seconds(); elapsed > 0 {
+			s.progressRate = (tr.DataDone - s.percentDone) / elapsed
+			s.hasProgressRate = true
+
+			// Counters restart from zero if torrent is re-added.
+			if tr.UploadedTotal >= s.uploadedTotal {
+				s.uploadRate = float64(tr.UploadedTotal-s.uploadedTotal) / elapsed
+				s.hasUploadRate = true
+			}
+
+			s.peakDownloadRate *= math.Pow(0.5, elapsed/peakRateHalfLife.Seconds())
+		}
+	}
+	if rate := float64(tr.DownloadRate); rate > s.peakDownloadRate {
+		s.peakDownloadRate = rate
+	}
+
+	seeding := s.status == transmission.StatusSeed || s.status == transmission.StatusSeedWait
+	if !s.updated.IsZero() && seeding && tr.Status == transmission.StatusStopped && tr.IsFinished {
+		s.seedingStopped = now
+	}
+	s.status = tr.Status
+
+	s.updated = now
+	s.percentDone = tr.DataDone
+	s.uploadedTotal = tr.UploadedTotal
+}
+
+func (t *TransmissionCollector) collectTorrentStatus(ch chan<- prometheus.Metric, _ sessionFunc, _ freeSpaceFunc) error {
+	ctx, cancel := t.newContext()
+	defer cancel()
+
+	torrents, err := t.client.GetTorrents(ctx, transmission.All(), transmission.TorrentFieldStatus)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(t.torrentsDesc, err)
+		return err
+	}
+	t.markSuccess()
+
+	byStatus := make(map[string]int, len(statuses))
+	for _, status := range statuses {
+		byStatus[status] = 0
+	}
+	for _, tr := range torrents {
+		if status, ok := statuses[tr.Status]; ok {
+			byStatus[status]++
+		}
+	}
+	for status, n := range byStatus {
+		ch <- prometheus.MustNewConstMetric(t.torrentsDesc, prometheus.GaugeValue, float64(n), status)
+	}
+
+	return nil
+}
+
+func (t *TransmissionCollector) collectPeers(ch chan<- prometheus.Metric, session sessionFunc, _ freeSpaceFunc) error {
+	ctx, cancel := t.newContext()
+	defer cancel()
+
+	var fields []transmission.TorrentField
+	if t.exposed(t.peersConnectedDesc, t.peerLimitUtilizationDesc) {
+		fields = append(fields, transmission.TorrentFieldConnectedPeers)
+	}
+	if t.exposed(t.peersSendingDesc) {
+		fields = append(fields, transmission.TorrentFieldPeersSendingToUs)
+	}
+	if t.exposed(t.peersReceivingDesc) {
+		fields = append(fields, transmission.TorrentFieldPeersGettingFromUs)
+	}
+	torrents, err := t.client.GetTorrents(ctx, transmission.All(), fields...)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(t.peersConnectedDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.peersSendingDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.peersReceivingDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.peerLimitUtilizationDesc, err)
+		return err
+	}
+	t.markSuccess()
+
+	var connected, sending, receiving int
+	for _, tr := range torrents {
+		connected += tr.ConnectedPeers
+		sending += tr.PeersSendingToUs
+		receiving += tr.PeersGettingFromUs
+	}
+	ch <- prometheus.MustNewConstMetric(t.peersConnectedDesc, prometheus.GaugeValue, float64(connected))
+	ch <- prometheus.MustNewConstMetric(t.peersSendingDesc, prometheus.GaugeValue, float64(sending))
+	ch <- prometheus.MustNewConstMetric(t.peersReceivingDesc, prometheus.GaugeValue, float64(receiving))
+
+	if !t.exposed(t.peerLimitUtilizationDesc) {
+		return nil
+	}
+	sess, err := session()
+	switch {
+	case err != nil:
+		ch <- prometheus.NewInvalidMetric(t.peerLimitUtilizationDesc, err)
+		return err
+	case sess.GlobalPeerLimit > 0:
+		ch <- prometheus.MustNewConstMetric(t.peerLimitUtilizationDesc, prometheus.GaugeValue,
+			float64(connected)/float64(sess.GlobalPeerLimit))
+	}
+
+	return nil
+}
+
+func (t *TransmissionCollector) collectTorrents(ch chan<- prometheus.Metric, session sessionFunc, freeSpace freeSpaceFunc) error {
+	ctx, cancel := t.newContext()
+	defer cancel()
+
+	// Some fields are expensive for Transmission to produce, only request the ones
+	// exposed metrics are computed from.
+	torrents, err := t.client.GetTorrents(ctx, transmission.All(), t.torrentFields()...)
+	if err != nil {
+		for _, desc := range t.torrentDescs() {
+			ch <- prometheus.NewInvalidMetric(desc, err)
+		}
+		return err
+	}
+	t.markSuccess()
+
+	now := time.Now()
+
+	err = t.collectTorrentAggregates(ch, session, freeSpace, torrents, now)
+	if t.preset >= PresetFull {
+		if detailsErr := t.collectTorrentDetails(ch, session, freeSpace, torrents, now); detailsErr != nil {
+			err = detailsErr
+		}
+	}
+
+	return err
+}
+
+// torrentDescs returns descriptors of metrics emitted by collectTorrents.
+func (t *TransmissionCollector) torrentDescs() []*prometheus.Desc {
+	descs := []*prometheus.Desc{
+		t.torrentsQueuedCheckDesc,
+		t.torrentsCheckingDesc,
+		t.torrentsDownloadWaitDesc,
+		t.torrentsSeedWaitDesc,
+		t.torrentsByErrorDesc,
+		t.torrentsByPriorityDesc,
+		t.torrentsBySeedRatioModeDesc,
+		t.torrentsByDirDesc,
+		t.averageAvailabilityRatioDesc,
+		t.torrentsIncompleteOldDesc,
+		t.latestTorrentAddedDesc,
+		t.totalLeftBytesDesc,
+		t.sufficientSpaceDesc,
+		t.distinctTrackersDesc,
+		t.trackersAnnouncingDesc,
+	}
+	if t.supportsRPC(rpcVersionLabels) {
+		descs = append(descs, t.labelDownloadRateDesc, t.labelUploadRateDesc)
+	}
+	if t.supportsRPC(rpcVersionQueue) {
+		descs = append(descs, t.downloadQueueSlotsFreeDesc)
+	}
+
+	return append(descs, t.torrentDetailDescs()...)
+}
+
+// torrentDetailDescs returns descriptors of per-torrent and per-tracker metrics
+// emitted by collectTorrents.
+func (t *TransmissionCollector) torrentDetailDescs() []*prometheus.Desc {
+	if t.preset < PresetFull {
+		return nil
+	}
+
+	descs := []*prometheus.Desc{
+		t.torrentsStatusChangedDesc,
+		t.trackerDownloadedCountDesc,
+		t.trackerDownSecondsDesc,
+		t.torrentProgressRateDesc,
+		t.torrentEstimatedETADesc,
+		t.torrentInIncompleteDirDesc,
+		t.torrentDownloadEfficiencyDesc,
+		t.torrentSeedingStoppedDesc,
+		t.torrentTrackerWarningsDesc,
+		t.torrentSeedPeerRatioDesc,
+		t.torrentActiveTrackersDesc,
+		t.torrentTotalTrackersDesc,
+		t.torrentDownloadLimitDesc,
+		t.torrentDownloadLimitedDesc,
+		t.torrentWantedCoverageDesc,
+		t.torrentHasMetadataDesc,
+		t.torrentProgressRatioDesc,
+		t.torrentDownloadSpeedDesc,
+		t.torrentUploadSpeedDesc,
+		t.torrentSizeDesc,
+		t.torrentDownloadedDesc,
+		t.torrentUploadedDesc,
+		t.torrentVerifiedPiecesDesc,
+		t.torrentSecondsToRatioGoalDesc,
+		t.torrentEffectiveRatioDesc,
+		t.torrentErrorDesc,
+		t.torrentDirFreeSpaceDesc,
+		t.torrentRatioDesc,
+		t.torrentETADesc,
+		t.torrentSinceActiveDesc,
+		t.torrentDownloadingPeersDesc,
+	}
+	if t.torrentWebseeds {
+		descs = append(descs, t.torrentWebseedCountDesc)
+	}
+	if t.torrentPeers {
+		descs = append(descs, t.torrentDistinctPeerIPsDesc)
+	}
+	if t.torrentFiles {
+		descs = append(descs, t.torrentActiveFileInfoDesc)
+	}
+
+	return descs
+}
+
+// torrentFields returns torrent fields needed by the exposed metrics of collectTorrents.
+func (t *TransmissionCollector) torrentFields() []transmission.TorrentField {
+	emitted := make(map[*prometheus.Desc]bool)
+	for _, desc := range t.torrentDescs() {
+		emitted[desc] = !t.denied[desc]
+	}
+
+	// Per-torrent metrics are labeled by hash, and skipped for idle torrents.
+	details := t.torrentDetailDescs()
+	var rateFiltered []*prometheus.Desc
+	if t.minTorrentRate > 0 {
+		rateFiltered = details
+	}
+
+	byField := []struct {
+		field transmission.TorrentField
+		descs []*prometheus.Desc
+	}{
+		{transmission.TorrentFieldHash, details},
+		{transmission.TorrentFieldName, []*prometheus.Desc{
+			t.torrentProgressRatioDesc, t.torrentDownloadSpeedDesc, t.torrentUploadSpeedDesc,
+			t.torrentSizeDesc, t.torrentDownloadedDesc, t.torrentUploadedDesc,
+		}},
+		{transmission.TorrentFieldDataDone, []*prometheus.Desc{
+			t.torrentsIncompleteOldDesc, t.torrentProgressRatioDesc, t.torrentProgressRateDesc,
+			t.torrentEstimatedETADesc, t.torrentInIncompleteDirDesc, t.torrentETADesc,
+		}},
+		{transmission.TorrentFieldErrorType, []*prometheus.Desc{t.torrentsByErrorDesc, t.torrentErrorDesc}},
+		{transmission.TorrentFieldError, []*prometheus.Desc{t.torrentErrorDesc}},
+		{transmission.TorrentFieldDownloadDirectory, []*prometheus.Desc{
+			t.torrentsByDirDesc, t.sufficientSpaceDesc, t.torrentInIncompleteDirDesc, t.torrentDirFreeSpaceDesc,
+		}},
+		{transmission.TorrentFieldStatus, []*prometheus.Desc{
+			t.torrentsQueuedCheckDesc, t.torrentsCheckingDesc, t.torrentsDownloadWaitDesc, t.torrentsSeedWaitDesc,
+			t.averageAvailabilityRatioDesc,
+			t.totalLeftBytesDesc, t.downloadQueueSlotsFreeDesc, t.torrentsStatusChangedDesc, t.torrentDownloadEfficiencyDesc,
+			t.torrentSeedingStoppedDesc, t.torrentVerifiedPiecesDesc,
+		}},
+		{transmission.TorrentFieldWantedAvailable, []*prometheus.Desc{t.averageAvailabilityRatioDesc}},
+		{transmission.TorrentFieldWantedLeft, []*prometheus.Desc{
+			t.averageAvailabilityRatioDesc, t.totalLeftBytesDesc, t.sufficientSpaceDesc,
+		}},
+		{transmission.TorrentFieldPriority, []*prometheus.Desc{t.torrentsByPriorityDesc}},
+		{transmission.TorrentFieldAddedAt, []*prometheus.Desc{t.torrentsIncompleteOldDesc, t.latestTorrentAddedDesc}},
+		{transmission.TorrentFieldTrackerStats, []*prometheus.Desc{
+			t.distinctTrackersDesc, t.trackersAnnouncingDesc, t.trackerDownloadedCountDesc, t.trackerDownSecondsDesc,
+			t.torrentTrackerWarningsDesc, t.torrentSeedPeerRatioDesc, t.torrentActiveTrackersDesc,
+			t.torrentTotalTrackersDesc,
+		}},
+		{transmission.TorrentFieldDownloadRate, append([]*prometheus.Desc{
+			t.labelDownloadRateDesc, t.torrentDownloadSpeedDesc, t.torrentDownloadEfficiencyDesc,
+		}, rateFiltered...)},
+		{transmission.TorrentFieldUploadRate, append([]*prometheus.Desc{
+			t.labelUploadRateDesc, t.torrentUploadSpeedDesc,
+		}, rateFiltered...)},
+		{transmission.TorrentFieldIsFinished, []*prometheus.Desc{t.torrentSeedingStoppedDesc}},
+		{transmission.TorrentFieldDownloadRateLimit, []*prometheus.Desc{t.torrentDownloadLimitDesc}},
+		{transmission.TorrentFieldDownloadRateLimitEnabled, []*prometheus.Desc{t.torrentDownloadLimitedDesc}},
+		{transmission.TorrentFieldWantedSize, []*prometheus.Desc{
+			t.torrentWantedCoverageDesc, t.torrentSecondsToRatioGoalDesc, t.torrentSizeDesc,
+		}},
+		{transmission.TorrentFieldTotalSize, []*prometheus.Desc{t.torrentWantedCoverageDesc}},
+		{transmission.TorrentFieldMetadataDone, []*prometheus.Desc{t.torrentHasMetadataDesc}},
+		{transmission.TorrentFieldUploadRatioLimitMode, []*prometheus.Desc{
+			t.torrentsBySeedRatioModeDesc, t.torrentSecondsToRatioGoalDesc, t.torrentEffectiveRatioDesc,
+		}},
+		{transmission.TorrentFieldUploadRatioLimit, []*prometheus.Desc{
+			t.torrentSecondsToRatioGoalDesc, t.torrentEffectiveRatioDesc,
+		}},
+		{transmission.TorrentFieldDataChecked, []*prometheus.Desc{t.torrentVerifiedPiecesDesc}},
+		{transmission.TorrentFieldPieceCount, []*prometheus.Desc{t.torrentVerifiedPiecesDesc}},
+		{transmission.TorrentFieldDownloadedTotal, []*prometheus.Desc{t.torrentSecondsToRatioGoalDesc, t.torrentDownloadedDesc}},
+		{transmission.TorrentFieldUploadedTotal, []*prometheus.Desc{t.torrentSecondsToRatioGoalDesc, t.torrentUploadedDesc}},
+		{transmission.TorrentFieldUploadRatio, []*prometheus.Desc{t.torrentRatioDesc}},
+		{transmission.TorrentFieldETA, []*prometheus.Desc{t.torrentETADesc}},
+		{transmission.TorrentFieldLastActiveAt, []*prometheus.Desc{t.torrentSinceActiveDesc}},
+		{transmission.TorrentFieldPeersSendingToUs, []*prometheus.Desc{t.torrentDownloadingPeersDesc}},
+		{transmission.TorrentFieldLabels, []*prometheus.Desc{t.labelDownloadRateDesc, t.labelUploadRateDesc}},
+		{transmission.TorrentFieldWebSeeds, []*prometheus.Desc{t.torrentWebseedCountDesc}},
+		{transmission.TorrentFieldPeers, []*prometheus.Desc{t.torrentDistinctPeerIPsDesc}},
+		{transmission.TorrentFieldFiles, []*prometheus.Desc{t.torrentActiveFileInfoDesc}},
+		{transmission.TorrentFieldFileStats, []*prometheus.Desc{t.torrentActiveFileInfoDesc}},
+	}
+
+	var fields []transmission.TorrentField
+	for _, f := range byField {
+		for _, desc := range f.descs {
+			if emitted[desc] {
+				fields = append(fields, f.field)
+				break
+			}
+		}
+	}
+
+	return fields
+}
+
+// collectTorrentAggregates emits metrics aggregated over all torrents.
+func (t *TransmissionCollector) collectTorrentAggregates(ch chan<- prometheus.Metric, session sessionFunc, freeSpace freeSpaceFunc, torrents []*transmission.Torrent, now time.Time) error {
+	byError := make(map[string]int, len(errorCategories))
+	for _, category := range errorCategories {
+		byError[category] = 0
+	}
+	byPriority := make(map[string]int, len(priorities))
+	for _, priority := range priorities {
+		byPriority[priority] = 0
+	}
+	bySeedRatioMode := make(map[string]int, len(seedRatioModes))
+	for _, mode := range seedRatioModes {
+		bySeedRatioMode[mode] = 0
+	}
+	var desiredAvailable, leftUntilDone int64
+	var latestAdded time.Time
+	var incompleteOld, downloading, announcing, queuedCheck, checking, downloadWait, seedWait int
+	trackerHosts := make(map[string]struct{})
+	byDir := make(map[string]int)
+	leftByDir := make(map[string]int64)
+	downloadByLabel := make(map[string]int64)
+	uploadByLabel := make(map[string]int64)
+
+	for _, tr := range torrents {
+		byDir[tr.DownloadDirectory]++
+		leftByDir[tr.DownloadDirectory] += tr.WantedLeft
+		if category, ok := errorCategories[tr.ErrorType]; ok {
+			byError[category]++
+		}
+		if priority, ok := priorities[tr.Priority]; ok {
+			byPriority[priority]++
+		}
+		if mode, ok := seedRatioModes[tr.UploadRatioLimitMode]; ok {
+			bySeedRatioMode[mode]++
+		}
+		if tr.AddedAt.After(latestAdded) {
+			latestAdded = tr.AddedAt
+		}
+		if tr.DataDone < 1 && now.Sub(tr.AddedAt) > t.incompleteAgeThreshold {
+			incompleteOld++
+		}
+		switch tr.Status {
+		case transmission.StatusCheckWait:
+			queuedCheck++
+		case transmission.StatusCheck:
+			checking++
+		case transmission.StatusDownloadWait:
+			downloadWait++
+		case transmission.StatusSeedWait:
+			seedWait++
+		case transmission.StatusDownload:
+			downloading++
+			desiredAvailable += tr.WantedAvailable
+			leftUntilDone += tr.WantedLeft
+		}
+		for _, ts := range tr.TrackerStats {
+			trackerHosts[trackerHost(ts.AnnounceURL)] = struct{}{}
+			if ts.AnnounceState == transmission.TrackerStateActive || ts.ScrapeState == transmission.TrackerStateActive {
+				announcing++
+			}
+		}
+		for _, label := range tr.Labels {
+			downloadByLabel[label] += tr.DownloadRate
+			uploadByLabel[label] += tr.UploadRate
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(t.torrentsQueuedCheckDesc, prometheus.GaugeValue, float64(queuedCheck))
+	ch <- prometheus.MustNewConstMetric(t.torrentsCheckingDesc, prometheus.GaugeValue, float64(checking))
+	ch <- prometheus.MustNewConstMetric(t.torrentsDownloadWaitDesc, prometheus.GaugeValue, float64(downloadWait))
+	ch <- prometheus.MustNewConstMetric(t.torrentsSeedWaitDesc, prometheus.GaugeValue, float64(seedWait))
+	for category, n := range byError {
+		ch <- prometheus.MustNewConstMetric(t.torrentsByErrorDesc, prometheus.GaugeValue, float64(n), category)
+	}
+	for priority, n := range byPriority {
+		ch <- prometheus.MustNewConstMetric(t.torrentsByPriorityDesc, prometheus.GaugeValue, float64(n), priority)
+	}
+	for mode, n := range bySeedRatioMode {
+		ch <- prometheus.MustNewConstMetric(t.torrentsBySeedRatioModeDesc, prometheus.GaugeValue, float64(n), mode)
+	}
+	for dir, n := range byDir {
+		ch <- prometheus.MustNewConstMetric(t.torrentsByDirDesc, prometheus.GaugeValue, float64(n), dir)
+	}
+
+	// Nothing left to download means that everything needed is available.
+	availability := 1.
+	if leftUntilDone > 0 {
+		availability = float64(desiredAvailable) / float64(leftUntilDone)
+	}
+	ch <- prometheus.MustNewConstMetric(t.averageAvailabilityRatioDesc, prometheus.GaugeValue, availability)
+	ch <- prometheus.MustNewConstMetric(t.torrentsIncompleteOldDesc, prometheus.GaugeValue, float64(incompleteOld))
+	if !latestAdded.IsZero() {
+		ch <- prometheus.MustNewConstMetric(t.latestTorrentAddedDesc, prometheus.GaugeValue, float64(latestAdded.Unix()))
+	}
+	ch <- prometheus.MustNewConstMetric(t.totalLeftBytesDesc, prometheus.GaugeValue, float64(leftUntilDone))
+	ch <- prometheus.MustNewConstMetric(t.distinctTrackersDesc, prometheus.GaugeValue, float64(len(trackerHosts)))
+	ch <- prometheus.MustNewConstMetric(t.trackersAnnouncingDesc, prometheus.GaugeValue, float64(announcing))
+	for label, rate := range downloadByLabel {
+		ch <- prometheus.MustNewConstMetric(t.labelDownloadRateDesc, prometheus.GaugeValue, float64(rate), label)
+	}
+	for label, rate := range uploadByLabel {
+		ch <- prometheus.MustNewConstMetric(t.labelUploadRateDesc, prometheus.GaugeValue, float64(rate), label)
+	}
+
+	var lastErr error
+	if t.supportsRPC(rpcVersionQueue) && t.exposed(t.downloadQueueSlotsFreeDesc) {
+		sess, err := session()
+		switch {
+		case err != nil:
+			ch <- prometheus.NewInvalidMetric(t.downloadQueueSlotsFreeDesc, err)
+			lastErr = err
+		case sess.DownloadQueueLimitEnabled:
+			// Forced torrents bypass the queue, so there might be more downloading torrents than slots.
+			free := sess.DownloadQueueLimit - downloading
+			if free < 0 {
+				free = 0
+			}
+			ch <- prometheus.MustNewConstMetric(t.downloadQueueSlotsFreeDesc, prometheus.GaugeValue, float64(free))
+		}
+	}
+
+	if !t.exposed(t.sufficientSpaceDesc) {
+		return lastErr
+	}
+	for dir, left := range leftByDir {
+		free, err := freeSpace(dir)
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(t.sufficientSpaceDesc, err)
+			lastErr = err
+			continue
+		}
+
+		val := 0.
+		if free >= left {
+			val = 1.
+		}
+		ch <- prometheus.MustNewConstMetric(t.sufficientSpaceDesc, prometheus.GaugeValue, val, dir)
+	}
+
+	return lastErr
+}
+
+// collectTorrentDetails emits per-torrent and per-tracker metrics.
+func (t *TransmissionCollector) collectTorrentDetails(ch chan<- prometheus.Metric, session sessionFunc, freeSpace freeSpaceFunc, torrents []*transmission.Torrent, now time.Time) error {
+	var sess *transmission.Session
+	var err error
+	if t.exposed(t.torrentInIncompleteDirDesc, t.torrentSecondsToRatioGoalDesc, t.torrentEffectiveRatioDesc) {
+		sess, err = session()
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(t.torrentInIncompleteDirDesc, err)
+			ch <- prometheus.NewInvalidMetric(t.torrentSecondsToRatioGoalDesc, err)
+			ch <- prometheus.NewInvalidMetric(t.torrentEffectiveRatioDesc, err)
+		}
+	}
+
+	// Torrents usually share a few directories, so free space is requested once per directory.
+	dirFree := make(map[string]int64)
+	if t.exposed(t.torrentDirFreeSpaceDesc) {
+		failed := make(map[string]bool)
+		for _, tr := range torrents {
+			if _, ok := dirFree[tr.DownloadDirectory]; ok || failed[tr.DownloadDirectory] {
+				continue
+			}
+			free, freeErr := freeSpace(tr.DownloadDirectory)
+			if freeErr != nil {
+				ch <- prometheus.NewInvalidMetric(t.torrentDirFreeSpaceDesc, freeErr)
+				failed[tr.DownloadDirectory] = true
+				err = freeErr
+				continue
+			}
+			dirFree[tr.DownloadDirectory] = free
+		}
+	}
+
+	var statusChanged int
+	trackerDownloaded := make(map[string]int)
+	// Whether any announce to the tracker host has succeeded, for hosts that were announced to.
+	trackerUp := make(map[string]bool)
+
+	t.torrentsMu.Lock()
+	defer t.torrentsMu.Unlock()
+
+	// Rebuild the state map on every scrape so that removed torrents are forgotten.
+	states := make(map[string]*torrentState, len(torrents))
+	for _, tr := range torrents {
+		for host, n := range trackerDownloadCounts(tr.TrackerStats) {
+			trackerDownloaded[host] += n
+		}
+		for _, ts := range tr.TrackerStats {
+			// Empty result means the tracker hasn't been announced to yet.
+			if ts.LastAnnounceResult == "" && !ts.IsLastAnnounceSucceeded {
+				continue
+			}
+			host := trackerHost(ts.AnnounceURL)
+			trackerUp[host] = trackerUp[host] || ts.IsLastAnnounceSucceeded
+		}
+
+		hash := string(tr.Hash)
+		st, ok := t.torrents[hash]
+		if !ok {
+			st = &torrentState{}
+		} else if st.status != tr.Status {
+			statusChanged++
+		}
+		st.update(tr, now)
+		states[hash] = st
+
+		if t.minTorrentRate > 0 && tr.DownloadRate+tr.UploadRate <= t.minTorrentRate {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(t.torrentProgressRatioDesc, prometheus.GaugeValue, tr.DataDone, hash, tr.Name)
+		ch <- prometheus.MustNewConstMetric(t.torrentDownloadSpeedDesc, prometheus.GaugeValue, float64(tr.DownloadRate), hash, tr.Name)
+		ch <- prometheus.MustNewConstMetric(t.torrentUploadSpeedDesc, prometheus.GaugeValue, float64(tr.UploadRate), hash, tr.Name)
+		// Transmission resets transfer totals if torrent is re-added, so they aren't counters.
+		ch <- prometheus.MustNewConstMetric(t.torrentSizeDesc, prometheus.GaugeValue, float64(tr.WantedSize), hash, tr.Name)
+		ch <- prometheus.MustNewConstMetric(t.torrentDownloadedDesc, prometheus.GaugeValue, float64(tr.DownloadedTotal), hash, tr.Name)
+		ch <- prometheus.MustNewConstMetric(t.torrentUploadedDesc, prometheus.GaugeValue, float64(tr.UploadedTotal), hash, tr.Name)
+
+		if st.hasProgressRate {
+			ch <- prometheus.MustNewConstMetric(t.torrentProgressRateDesc, prometheus.GaugeValue, st.progressRate, hash)
+
+			eta := math.NaN()
+			switch {
+			case tr.DataDone >= 1:
+				eta = 0
+			case st.progressRate > 0:
+				eta = (1 - tr.DataDone) / st.progressRate
+			}
+			ch <- prometheus.MustNewConstMetric(t.torrentEstimatedETADesc, prometheus.GaugeValue, eta, hash)
+		}
+		if sess != nil {
+			val := 0.
+			if inIncompleteDir(tr, sess) {
+				val = 1.
+			}
+			ch <- prometheus.MustNewConstMetric(t.torrentInIncompleteDirDesc, prometheus.GaugeValue, val, hash)
+		}
+		// Transmission reports -1 for unknown ratio and -2 for infinite one.
+		switch {
+		case tr.UploadRatio >= 0:
+			ch <- prometheus.MustNewConstMetric(t.torrentRatioDesc, prometheus.GaugeValue, tr.UploadRatio, hash)
+		case tr.UploadRatio == -2:
+			ch <- prometheus.MustNewConstMetric(t.torrentRatioDesc, prometheus.GaugeValue, math.Inf(1), hash)
+		}
+		if goal, ok := seedRatioGoal(tr, sess); ok {
+			ch <- prometheus.MustNewConstMetric(t.torrentEffectiveRatioDesc, prometheus.GaugeValue, goal, hash)
+			if st.hasUploadRate {
+				ch <- prometheus.MustNewConstMetric(t.torrentSecondsToRatioGoalDesc, prometheus.GaugeValue,
+					secondsToRatioGoal(tr, goal, st.uploadRate), hash)
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(t.torrentDownloadingPeersDesc, prometheus.GaugeValue, float64(tr.PeersSendingToUs), hash)
+		// Transmission reports negative ETA if it is not available or can't be estimated.
+		eta := 0.
+		switch {
+		case tr.ETA > 0:
+			eta = tr.ETA.Seconds()
+		case tr.DataDone < 1:
+			eta = -1
+		}
+		ch <- prometheus.MustNewConstMetric(t.torrentETADesc, prometheus.GaugeValue, eta, hash)
+		// Activity date is zero for torrents that never transferred data.
+		if !tr.LastActiveAt.IsZero() {
+			ch <- prometheus.MustNewConstMetric(t.torrentSinceActiveDesc, prometheus.GaugeValue,
+				now.Sub(tr.LastActiveAt).Seconds(), hash)
+		}
+		if tr.Status == transmission.StatusDownload && st.peakDownloadRate > 0 {
+			ch <- prometheus.MustNewConstMetric(t.torrentDownloadEfficiencyDesc, prometheus.GaugeValue,
+				float64(tr.DownloadRate)/st.peakDownloadRate, hash)
+		}
+		if !st.seedingStopped.IsZero() {
+			ch <- prometheus.MustNewConstMetric(t.torrentSeedingStoppedDesc, prometheus.GaugeValue,
+				float64(st.seedingStopped.Unix()), hash)
+		}
+
+		var warnings, seeders, swarm, activeTrackers int
+		for _, ts := range tr.TrackerStats {
+			if ts.IsLastAnnounceSucceeded {
+				activeTrackers++
+			}
+			// Negative counts mean the tracker didn't report them.
+			if ts.Seeders >= 0 && ts.Leechers >= 0 && ts.Seeders+ts.Leechers > swarm {
+				seeders, swarm = ts.Seeders, ts.Seeders+ts.Leechers
+			}
+			// Failed announces are tracker errors. Successful ones report "Success"
+			// unless tracker attached a warning message to the response.
+			if ts.IsLastAnnounceSucceeded && ts.LastAnnounceResult != "" && ts.LastAnnounceResult != "Success" {
+				warnings++
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(t.torrentTrackerWarningsDesc, prometheus.GaugeValue, float64(warnings), hash)
+		if swarm > 0 {
+			ch <- prometheus.MustNewConstMetric(t.torrentSeedPeerRatioDesc, prometheus.GaugeValue, float64(seeders)/float64(swarm), hash)
+		}
+		ch <- prometheus.MustNewConstMetric(t.torrentActiveTrackersDesc, prometheus.GaugeValue, float64(activeTrackers), hash)
+		ch <- prometheus.MustNewConstMetric(t.torrentTotalTrackersDesc, prometheus.GaugeValue, float64(len(tr.TrackerStats)), hash)
+
+		ch <- prometheus.MustNewConstMetric(t.torrentDownloadLimitDesc, prometheus.GaugeValue, float64(tr.DownloadRateLimit), hash)
+		limited := 0.
+		if tr.DownloadRateLimitEnabled {
+			limited = 1.
+		}
+		ch <- prometheus.MustNewConstMetric(t.torrentDownloadLimitedDesc, prometheus.GaugeValue, limited, hash)
+
+		// Total size is unknown until magnet links receive metadata.
+		if tr.TotalSize > 0 {
+			ch <- prometheus.MustNewConstMetric(t.torrentWantedCoverageDesc, prometheus.GaugeValue,
+				float64(tr.WantedSize)/float64(tr.TotalSize), hash)
+		}
+		if tr.Status == transmission.StatusCheck {
+			ch <- prometheus.MustNewConstMetric(t.torrentVerifiedPiecesDesc, prometheus.GaugeValue,
+				math.Floor(tr.DataChecked*float64(tr.PieceCount)), hash)
+		}
+		if free, ok := dirFree[tr.DownloadDirectory]; ok {
+			ch <- prometheus.MustNewConstMetric(t.torrentDirFreeSpaceDesc, prometheus.GaugeValue, float64(free), hash, tr.DownloadDirectory)
+		}
+		hasMetadata := 0.
+		if tr.MetadataDone >= 1 {
+			hasMetadata = 1.
+		}
+		ch <- prometheus.MustNewConstMetric(t.torrentHasMetadataDesc, prometheus.GaugeValue, hasMetadata, hash)
+
+		// Error strings are free-form, so they are logged rather than exposed as labels.
+		if category, ok := errorCategories[tr.ErrorType]; ok {
+			for _, c := range errorCategories {
+				val := 0.
+				if c == category {
+					val = 1.
+				}
+				ch <- prometheus.MustNewConstMetric(t.torrentErrorDesc, prometheus.GaugeValue, val, hash, c)
+			}
+		}
+		if tr.ErrorType != transmission.ErrorTypeOK {
+			level.Debug(t.logger).Log("msg", "torrent is in error state", "hash", hash, "error", tr.Error)
+		}
+
+		if t.torrentWebseeds {
+			ch <- prometheus.MustNewConstMetric(t.torrentWebseedCountDesc, prometheus.GaugeValue, float64(len(tr.WebSeeds)), hash)
+		}
+		if t.torrentPeers {
+			ips := make(map[string]struct{}, len(tr.Peers))
+			for _, peer := range tr.Peers {
+				ips[peer.Address.String()] = struct{}{}
+			}
+			ch <- prometheus.MustNewConstMetric(t.torrentDistinctPeerIPsDesc, prometheus.GaugeValue, float64(len(ips)), hash)
+		}
+		if t.torrentFiles {
+			if file, ok := activeFile(tr); ok {
+				ch <- prometheus.MustNewConstMetric(t.torrentActiveFileInfoDesc, prometheus.GaugeValue, 1, hash, file)
+			}
+		}
+	}
+	t.torrents = states
+	ch <- prometheus.MustNewConstMetric(t.torrentsStatusChangedDesc, prometheus.GaugeValue, float64(statusChanged))
+
+	for host, n := range trackerDownloaded {
+		ch <- prometheus.MustNewConstMetric(t.trackerDownloadedCountDesc, prometheus.GaugeValue, float64(n), host)
+	}
+
+	// Rebuild the state map on every scrape so that trackers no longer used are forgotten.
+	downSince := make(map[string]time.Time, len(trackerUp))
+	for host, up := range trackerUp {
+		down := 0.
+		if !up {
+			since, ok := t.trackersDownSince[host]
+			if !ok {
+				since = now
+			}
+			downSince[host] = since
+			down = now.Sub(since).Seconds()
+		}
+		ch <- prometheus.MustNewConstMetric(t.trackerDownSecondsDesc, prometheus.GaugeValue, down, host)
+	}
+	t.trackersDownSince = downSince
+
+	return err
+}
+
+func (t *TransmissionCollector) collectTrackerStats(ch chan<- prometheus.Metric, _ sessionFunc, _ freeSpaceFunc) error {
+	ctx, cancel := t.newContext()
+	defer cancel()
+
+	fields := []transmission.TorrentField{transmission.TorrentFieldTrackerStats}
+	if t.exposed(t.trackerSeederCountDesc, t.trackerLeecherCountDesc, t.torrentAnnouncePeerCountDesc) {
+		fields = append(fields, transmission.TorrentFieldHash)
+	}
+	torrents, err := t.client.GetTorrents(ctx, transmission.All(), fields...)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(t.trackerSeederCountDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.trackerLeecherCountDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.torrentAnnouncePeerCountDesc, err)
+		ch <- prometheus.NewInvalidMetric(t.trackerAnnounceErrorsDesc, err)
+		return err
+	}
+	t.markSuccess()
+
+	var announceErrors int
+	for _, tr := range torrents {
+		hash := string(tr.Hash)
+		for _, ts := range tr.TrackerStats {
+			// Empty result means the tracker hasn't been announced to yet.
+			if !ts.IsLastAnnounceSucceeded && ts.LastAnnounceResult != "" {
+				announceErrors++
+			}
+		}
+
+		seeders := trackerCounts(tr.TrackerStats, func(ts transmission.TrackerStat) int { return ts.Seeders })
+		for host, n := range seeders {
+			ch <- prometheus.MustNewConstMetric(t.trackerSeederCountDesc, prometheus.GaugeValue, float64(n), host, hash)
+		}
+		leechers := trackerCounts(tr.TrackerStats, func(ts transmission.TrackerStat) int { return ts.Leechers })
+		for host, n := range leechers {
+			ch <- prometheus.MustNewConstMetric(t.trackerLeecherCountDesc, prometheus.GaugeValue, float64(n), host, hash)
+		}
+		peers := trackerCounts(tr.TrackerStats, func(ts transmission.TrackerStat) int {
+			// Peer count is meaningless until the tracker has been announced to.
+			if ts.LastAnnounceResult == "" && !ts.IsLastAnnounceSucceeded {
+				return -1
+			}
+			return ts.LastAnnouncePeerCount
+		})
+		for host, n := range peers {
+			ch <- prometheus.MustNewConstMetric(t.torrentAnnouncePeerCountDesc, prometheus.GaugeValue, float64(n), hash, host)
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(t.trackerAnnounceErrorsDesc, prometheus.GaugeValue, float64(announceErrors))
+
+	return nil
+}
+
+// trackerHost returns host part of tracker announce URL.
+func trackerHost(announce *url.URL) string {
+	if announce == nil {
+		return ""
+	}
+	if host := announce.Hostname(); host != "" {
+		return host
+	}
+
+	return announce.String()
+}
+
+// trackerDownloadCounts returns download counts reported by trackers of a single torrent
+// keyed by tracker host.
+func trackerDownloadCounts(stats []transmission.TrackerStat) map[string]int {
+	return trackerCounts(stats, func(ts transmission.TrackerStat) int { return ts.Downloads })
+}
+
+// trackerCounts returns counts reported by trackers of a single torrent keyed by
+// tracker host. A torrent might list the same host several times (e.g. in different
+// tiers), so the highest count is used. Unknown (negative) counts are skipped.
+func trackerCounts(stats []transmission.TrackerStat, count func(transmission.TrackerStat) int) map[string]int {
+	counts := make(map[string]int)
+	for _, ts := range stats {
+		n := count(ts)
+		if n < 0 {
+			continue
+		}
+
+		host := trackerHost(ts.AnnounceURL)
+		if prev, ok := counts[host]; !ok || n > prev {
+			counts[host] = n
+		}
+	}
+
+	return counts
+}
+
+// activeFile returns name of the file Transmission is currently downloading. Pieces
+// of higher priority files are requested first, and within the same priority files
+// are fetched roughly in order, so this is the first unfinished wanted file of the
+// highest priority.
+func activeFile(tr *transmission.Torrent) (string, bool) {
+	active := -1
+	for i, fs := range tr.FileStats {
+		if i >= len(tr.Files) || !fs.Wanted || fs.Downloaded >= tr.Files[i].Size {
+			continue
+		}
+		if active < 0 || fs.Priority > tr.FileStats[active].Priority {
+			active = i
+		}
+	}
+	if active < 0 {
+		return "", false
+	}
+
+	return tr.Files[active].Name, true
+}
+
+// seedRatioGoal returns seed ratio limit in effect for torrent. sess might be nil if
+// session settings are unknown, in which case global limits can't be resolved.
+func seedRatioGoal(tr *transmission.Torrent, sess *transmission.Session) (float64, bool) {
+	switch tr.UploadRatioLimitMode {
+	case transmission.LimitLocal:
+		return tr.UploadRatioLimit, true
+	case transmission.LimitGlobal:
+		if sess != nil && sess.UploadRatioEnabled {
+			return sess.UploadRatio, true
+		}
+	}
+
+	return 0, false
+}
+
+// secondsToRatioGoal estimates how long it takes for torrent to reach the ratio goal
+// uploading at rate bytes per second. Like Transmission, the ratio is calculated
+// against the wanted size if nothing has been downloaded (e.g. the torrent was added
+// complete).
+func secondsToRatioGoal(tr *transmission.Torrent, goal, rate float64) float64 {
+	base := tr.DownloadedTotal
+	if base == 0 {
+		base = tr.WantedSize
+	}
+
+	left := goal*float64(base) - float64(tr.UploadedTotal)
+	switch {
+	case left <= 0:
+		return 0
+	case rate <= 0:
+		return math.NaN()
+	}
+
+	return left / rate
+}
+
+// inIncompleteDir reports whether torrent data is kept in the session incomplete directory.
+// Transmission reports the final destination as downloadDir, so unfinished torrents are
+// considered to be in the incomplete directory whenever it is enabled.
+func inIncompleteDir(tr *transmission.Torrent, sess *transmission.Session) bool {
+	if !sess.IncompleteDirectoryEnabled || sess.IncompleteDirectory == "" {
+		return false
+	}
+	if tr.DataDone < 1 {
+		return true
+	}
+
+	rel, err := filepath.Rel(filepath.Clean(sess.IncompleteDirectory), filepath.Clean(tr.DownloadDirectory))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
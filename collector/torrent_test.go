@@ -0,0 +1,266 @@
+package collector
+
+import (
+	"math"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pborzenkov/go-transmission/transmission"
+)
+
+func TestTorrentStateUpdate(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+
+	var s torrentState
+	s.update(&transmission.Torrent{
+		Status:        transmission.StatusSeed,
+		DataDone:      0.5,
+		DownloadRate:  1000,
+		UploadedTotal: 1000,
+	}, start)
+	if s.hasProgressRate || s.hasUploadRate {
+		t.Fatalf("rates are known after the first update")
+	}
+	if s.peakDownloadRate != 1000 {
+		t.Errorf("peakDownloadRate = %v, want 1000", s.peakDownloadRate)
+	}
+
+	// An hour later the peak rate decays by half.
+	s.update(&transmission.Torrent{
+		Status:        transmission.StatusStopped,
+		IsFinished:    true,
+		DataDone:      0.86,
+		UploadedTotal: 4600,
+	}, start.Add(time.Hour))
+	if !s.hasProgressRate || math.Abs(s.progressRate-0.0001) > 1e-12 {
+		t.Errorf("progressRate = %v, want 0.0001", s.progressRate)
+	}
+	if !s.hasUploadRate || s.uploadRate != 1 {
+		t.Errorf("uploadRate = %v, want 1", s.uploadRate)
+	}
+	if s.peakDownloadRate != 500 {
+		t.Errorf("peakDownloadRate = %v, want 500", s.peakDownloadRate)
+	}
+	if !s.seedingStopped.Equal(start.Add(time.Hour)) {
+		t.Errorf("seedingStopped = %v, want %v", s.seedingStopped, start.Add(time.Hour))
+	}
+
+	// Updates within the same instant don't change the rate.
+	s.update(&transmission.Torrent{
+		Status:        transmission.StatusStopped,
+		DataDone:      0.86,
+		UploadedTotal: 4600,
+	}, start.Add(time.Hour))
+	if math.Abs(s.progressRate-0.0001) > 1e-12 {
+		t.Errorf("progressRate after zero elapsed time = %v, want 0.0001", s.progressRate)
+	}
+
+	// Counters restart from zero if torrent is re-added, the upload rate is kept.
+	s.update(&transmission.Torrent{
+		Status:        transmission.StatusStopped,
+		DataDone:      0.86,
+		UploadedTotal: 0,
+	}, start.Add(2*time.Hour))
+	if s.uploadRate != 1 {
+		t.Errorf("uploadRate after counter reset = %v, want 1", s.uploadRate)
+	}
+	if s.uploadedTotal != 0 {
+		t.Errorf("uploadedTotal = %v, want 0", s.uploadedTotal)
+	}
+}
+
+func mustParseURL(t *testing.T, s string) *url.URL {
+	t.Helper()
+
+	u, err := url.Parse(s)
+	if err != nil {
+		t.Fatalf("url.Parse(%q): %s", s, err)
+	}
+
+	return u
+}
+
+func TestTrackerCounts(t *testing.T) {
+	stats := []transmission.TrackerStat{
+		{AnnounceURL: mustParseURL(t, "http://tracker.example.com:6969/announce"), Seeders: 5},
+		{AnnounceURL: mustParseURL(t, "udp://tracker.example.com:1337/announce"), Seeders: 7},
+		{AnnounceURL: mustParseURL(t, "http://other.example.org/announce"), Seeders: -1},
+		{AnnounceURL: mustParseURL(t, "http://third.example.net/announce"), Seeders: 0},
+	}
+
+	got := trackerCounts(stats, func(ts transmission.TrackerStat) int { return ts.Seeders })
+	want := map[string]int{
+		"tracker.example.com": 7,
+		"third.example.net":   0,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("trackerCounts() = %v, want %v", got, want)
+	}
+}
+
+func TestActiveFile(t *testing.T) {
+	files := []transmission.File{
+		{Name: "a", Size: 100},
+		{Name: "b", Size: 100},
+		{Name: "c", Size: 100},
+	}
+
+	tests := []struct {
+		name      string
+		fileStats []transmission.FileStat
+		want      string
+		wantOK    bool
+	}{
+		{
+			name: "first unfinished",
+			fileStats: []transmission.FileStat{
+				{Downloaded: 100, Wanted: true},
+				{Downloaded: 50, Wanted: true},
+				{Downloaded: 0, Wanted: true},
+			},
+			want:   "b",
+			wantOK: true,
+		},
+		{
+			name: "higher priority first",
+			fileStats: []transmission.FileStat{
+				{Downloaded: 0, Wanted: true},
+				{Downloaded: 0, Wanted: true},
+				{Downloaded: 0, Wanted: true, Priority: transmission.PriorityHigh},
+			},
+			want:   "c",
+			wantOK: true,
+		},
+		{
+			name: "unwanted skipped",
+			fileStats: []transmission.FileStat{
+				{Downloaded: 0, Wanted: false},
+				{Downloaded: 0, Wanted: true},
+				{Downloaded: 0, Wanted: true},
+			},
+			want:   "b",
+			wantOK: true,
+		},
+		{
+			name: "all done",
+			fileStats: []transmission.FileStat{
+				{Downloaded: 100, Wanted: true},
+				{Downloaded: 100, Wanted: true},
+				{Downloaded: 0, Wanted: false},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &transmission.Torrent{Files: files, FileStats: tt.fileStats}
+			got, ok := activeFile(tr)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("activeFile() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSecondsToRatioGoal(t *testing.T) {
+	tests := []struct {
+		name string
+		tr   transmission.Torrent
+		goal float64
+		rate float64
+		want float64
+	}{
+		{
+			name: "uploading",
+			tr:   transmission.Torrent{DownloadedTotal: 1000, UploadedTotal: 500},
+			goal: 2,
+			rate: 100,
+			want: 15,
+		},
+		{
+			name: "goal reached",
+			tr:   transmission.Torrent{DownloadedTotal: 1000, UploadedTotal: 2500},
+			goal: 2,
+			rate: 0,
+			want: 0,
+		},
+		{
+			name: "added complete",
+			tr:   transmission.Torrent{WantedSize: 1000},
+			goal: 1,
+			rate: 50,
+			want: 20,
+		},
+		{
+			name: "not uploading",
+			tr:   transmission.Torrent{DownloadedTotal: 1000},
+			goal: 1,
+			rate: 0,
+			want: math.NaN(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := secondsToRatioGoal(&tt.tr, tt.goal, tt.rate)
+			if got != tt.want && !(math.IsNaN(got) && math.IsNaN(tt.want)) {
+				t.Errorf("secondsToRatioGoal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInIncompleteDir(t *testing.T) {
+	sess := &transmission.Session{
+		IncompleteDirectory:        "/data/incomplete",
+		IncompleteDirectoryEnabled: true,
+	}
+
+	tests := []struct {
+		name string
+		tr   transmission.Torrent
+		sess *transmission.Session
+		want bool
+	}{
+		{
+			name: "unfinished",
+			tr:   transmission.Torrent{DownloadDirectory: "/data/complete", DataDone: 0.5},
+			sess: sess,
+			want: true,
+		},
+		{
+			name: "finished elsewhere",
+			tr:   transmission.Torrent{DownloadDirectory: "/data/complete", DataDone: 1},
+			sess: sess,
+			want: false,
+		},
+		{
+			name: "finished inside",
+			tr:   transmission.Torrent{DownloadDirectory: "/data/incomplete/sub/", DataDone: 1},
+			sess: sess,
+			want: true,
+		},
+		{
+			name: "finished in sibling with common prefix",
+			tr:   transmission.Torrent{DownloadDirectory: "/data/incomplete-old", DataDone: 1},
+			sess: sess,
+			want: false,
+		},
+		{
+			name: "disabled",
+			tr:   transmission.Torrent{DownloadDirectory: "/data/complete", DataDone: 0.5},
+			sess: &transmission.Session{IncompleteDirectory: "/data/incomplete"},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inIncompleteDir(&tt.tr, tt.sess); got != tt.want {
+				t.Errorf("inIncompleteDir() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
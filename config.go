@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// fileConfig describes settings that can be loaded from a configuration file.
+type fileConfig struct {
+	Transmission struct {
+		URL      string        `yaml:"url"`
+		Username string        `yaml:"username"`
+		Password string        `yaml:"password"`
+		Timeout  time.Duration `yaml:"timeout"`
+	} `yaml:"transmission"`
+}
+
+// loadConfig reads YAML configuration file at path. Unknown keys are rejected.
+func loadConfig(path string) (*fileConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read config file: %s", err)
+	}
+
+	cfg := new(fileConfig)
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("couldn't parse config file %s: %s", path, err)
+	}
+
+	return cfg, nil
+}
+
+// explicitSettings tells which Transmission connection settings were passed explicitly.
+type explicitSettings struct {
+	url, username, password, timeout bool
+}
+
+// apply sets Transmission connection settings that weren't passed explicitly to the
+// values from the configuration file. Settings missing from the file are left intact.
+func (c *fileConfig) apply(url, username, password *string, timeout *time.Duration, explicit explicitSettings) {
+	if c.Transmission.URL != "" && !explicit.url {
+		*url = c.Transmission.URL
+	}
+	if c.Transmission.Username != "" && !explicit.username {
+		*username = c.Transmission.Username
+	}
+	if c.Transmission.Password != "" && !explicit.password {
+		*password = c.Transmission.Password
+	}
+	if c.Transmission.Timeout != 0 && !explicit.timeout {
+		*timeout = c.Transmission.Timeout
+	}
+}
+
+// loadMetricAllowlist reads metric names from file at path, one per line. Empty lines
+// and lines starting with '#' are ignored.
+func loadMetricAllowlist(path string) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read metric allowlist: %s", err)
+	}
+
+	var names []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+
+	return names, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileConfigApply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	config := "transmission:\n  url: http://nas:9091\n  username: admin\n  password: secret\n  timeout: 10s\n"
+	if err := ioutil.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		explicit explicitSettings
+		want     [3]string
+		timeout  time.Duration
+	}{
+		{
+			name:    "file overrides",
+			want:    [3]string{"http://nas:9091", "admin", "secret"},
+			timeout: 10 * time.Second,
+		},
+		{
+			name:     "flags take precedence",
+			explicit: explicitSettings{url: true, username: true, password: true, timeout: true},
+			want:     [3]string{"http://127.0.0.1:9091", "flag-user", "flag-secret"},
+			timeout:  5 * time.Second,
+		},
+		{
+			name:     "password flag only",
+			explicit: explicitSettings{password: true},
+			want:     [3]string{"http://nas:9091", "admin", "flag-secret"},
+			timeout:  10 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, username, password, timeout := "http://127.0.0.1:9091", "flag-user", "flag-secret", 5*time.Second
+
+			cfg.apply(&url, &username, &password, &timeout, tt.explicit)
+			if got := [3]string{url, username, password}; got != tt.want {
+				t.Errorf("url, username, password = %q, want %q", got, tt.want)
+			}
+			if timeout != tt.timeout {
+				t.Errorf("timeout = %v, want %v", timeout, tt.timeout)
+			}
+		})
+	}
+}
+
+func TestLoadConfigRejectsUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := ioutil.WriteFile(path, []byte("transmission:\n  uri: http://nas:9091\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadConfig(path); err == nil {
+		t.Errorf("loadConfig() with unknown key succeeded, want error")
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pborzenkov/go-transmission/transmission"
+	"gopkg.in/yaml.v2"
+)
+
+// webConfigHasAuth reports whether web config file configures basic authentication.
+func webConfigHasAuth(path string) (bool, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("couldn't read web config file: %s", err)
+	}
+
+	var cfg struct {
+		Users map[string]string `yaml:"basic_auth_users"`
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return false, fmt.Errorf("couldn't parse web config file %s: %s", path, err)
+	}
+
+	return len(cfg.Users) > 0, nil
+}
+
+// newTurtleControlHandler returns handler that enables or disables turtle mode.
+func newTurtleControlHandler(trans *transmission.Client, logger log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		enabled, err := strconv.ParseBool(r.URL.Query().Get("enabled"))
+		if err != nil {
+			http.Error(w, "missing or invalid 'enabled' parameter", http.StatusBadRequest)
+			return
+		}
+
+		level.Info(logger).Log("msg", "Setting turtle mode", "enabled", enabled, "remote", r.RemoteAddr)
+		if err := trans.SetSession(r.Context(), &transmission.SetSessionReq{TurtleEnabled: &enabled}); err != nil {
+			level.Error(logger).Log("msg", "failed to set turtle mode", "err", err)
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
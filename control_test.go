@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestWebConfigHasAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   bool
+	}{
+		{
+			name:   "basic auth",
+			config: "basic_auth_users:\n  admin: $2y$10$X0h1gDsPszWURQaxFh.zoubFi6DXncSjhoQNJgRrnGs7EsimhC7zG\n",
+			want:   true,
+		},
+		{
+			name:   "tls only",
+			config: "tls_server_config:\n  cert_file: server.crt\n  key_file: server.key\n",
+			want:   false,
+		},
+		{
+			name:   "no users",
+			config: "basic_auth_users: {}\n",
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "web.yml")
+			if err := ioutil.WriteFile(path, []byte(tt.config), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := webConfigHasAuth(path)
+			if err != nil {
+				t.Fatalf("webConfigHasAuth() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("webConfigHasAuth() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := webConfigHasAuth(filepath.Join(t.TempDir(), "missing.yml")); err == nil {
+		t.Errorf("webConfigHasAuth() with missing file succeeded, want error")
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pborzenkov/go-transmission/transmission"
+)
+
+// sessionGetRequest is a raw session-get RPC call requesting all session fields.
+var sessionGetRequest = []byte(`{"method":"session-get"}`)
+
+// newSessionDebugHandler returns handler that dumps Transmission session-get response
+// as returned by Transmission, without converting it to the client representation.
+func newSessionDebugHandler(trans *transmission.Client, rpcURL string, logger log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, err := callRawRPC(r.Context(), trans, rpcURL, sessionGetRequest)
+		if err != nil {
+			level.Warn(logger).Log("msg", "failed to get session", "err", err)
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := io.Copy(w, resp.Body); err != nil {
+			level.Warn(logger).Log("msg", "failed to copy session", "err", err)
+		}
+	})
+}
+
+// callRawRPC sends RPC request to Transmission using HTTP client and credentials of
+// trans and returns the response as is. A CSRF token rejected by Transmission is
+// refreshed and the request is retried once.
+func callRawRPC(ctx context.Context, trans *transmission.Client, rpcURL string, body []byte) (*http.Response, error) {
+	var sessionID string
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, rpcURL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Transmission-Session-Id", sessionID)
+		if trans.Username != "" || trans.Password != "" {
+			req.SetBasicAuth(trans.Username, trans.Password)
+		}
+
+		resp, err := trans.HTTPClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode/100 == 2 {
+			return resp, nil
+		}
+
+		_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusConflict {
+			return nil, fmt.Errorf("transmission: HTTP request failed (%s)", http.StatusText(resp.StatusCode))
+		}
+		sessionID = resp.Header.Get("X-Transmission-Session-Id")
+	}
+
+	return nil, fmt.Errorf("transmission: CSRF token not accepted")
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/pborzenkov/go-transmission/transmission"
+)
+
+func TestSessionDebugHandler(t *testing.T) {
+	const session = `{"arguments":{"rpc-version":17,"speed-limit-down":100},"result":"success"}`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Transmission-Session-Id") != "token" {
+			w.Header().Set("X-Transmission-Session-Id", "token")
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.Write([]byte(session))
+	}))
+	defer srv.Close()
+
+	turl, err := rpcURL(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trans, err := transmission.New(turl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	newSessionDebugHandler(trans, turl, log.NewNopLogger()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/session", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if body, _ := ioutil.ReadAll(rec.Body); string(body) != session {
+		t.Errorf("body = %s, want %s", body, session)
+	}
+}
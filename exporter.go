@@ -3,11 +3,17 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	stdlog "log"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	kingpin "github.com/alecthomas/kingpin/v2"
 	"github.com/go-kit/log"
@@ -23,27 +29,106 @@ import (
 	"github.com/prometheus/exporter-toolkit/web/kingpinflag"
 )
 
-func newHandler(turl string, logger log.Logger) (http.Handler, error) {
-	var options []transmission.Option
+// clientConfig describes how to connect to Transmission RPC server.
+type clientConfig struct {
+	url          string
+	tunnel       *sshTunnel
+	username     string
+	password     string
+	passwordFile string
+	compression  bool
+	tls          *tls.Config
+}
 
+// rpcURL returns URL of Transmission RPC endpoint for the given server URL. Unix
+// sockets are dialed directly, so any host can be used for them.
+func rpcURL(turl string) (string, error) {
 	if strings.HasPrefix(turl, "unix://") {
-		sock := strings.TrimPrefix(turl, "unix://")
 		turl = "http://localhost"
-		options = append(options, transmission.WithHTTPClient(&http.Client{
-			Transport: &http.Transport{
-				DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
-					return net.Dial("unix", sock)
-				},
-			},
-		}))
 	}
 
+	u, err := url.Parse(turl)
+	if err != nil {
+		return "", fmt.Errorf("couldn't parse transmission URL: %s", err)
+	}
+	if u.Path == "" {
+		u.Path = "/transmission/rpc"
+	}
+
+	return u.String(), nil
+}
+
+func newClient(cfg clientConfig) (*transmission.Client, error) {
+	var options []transmission.Option
+
+	dial := (&net.Dialer{}).DialContext
+	if cfg.tunnel != nil {
+		dial = cfg.tunnel.DialContext
+	}
+
+	// Go transport transparently requests and decodes gzip-compressed responses
+	// unless compression is disabled.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableCompression = !cfg.compression
+	if cfg.tls != nil {
+		transport.TLSClientConfig = cfg.tls
+	}
+	switch {
+	case strings.HasPrefix(cfg.url, "unix://"):
+		sock := strings.TrimPrefix(cfg.url, "unix://")
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dial(ctx, "unix", sock)
+		}
+	case cfg.tunnel != nil:
+		transport.Proxy = nil
+		transport.DialContext = dial
+	}
+
+	if cfg.password != "" {
+		options = append(options, transmission.WithAuth(cfg.username, cfg.password))
+	}
+
+	var rt http.RoundTripper = transport
+	if cfg.passwordFile != "" {
+		rt = &passwordFileTransport{
+			username: cfg.username,
+			path:     cfg.passwordFile,
+			next:     rt,
+		}
+	}
+
+	options = append(options, transmission.WithHTTPClient(&http.Client{
+		Transport: rt,
+	}))
+
+	turl, err := rpcURL(cfg.url)
+	if err != nil {
+		return nil, err
+	}
 	trans, err := transmission.New(turl, options...)
 	if err != nil {
 		return nil, fmt.Errorf("couldn't create transmission client: %s", err)
 	}
 
-	tc, err := collector.NewTransmissionCollector(trans, logger)
+	return trans, nil
+}
+
+// detectRPCVersion returns RPC version spoken by Transmission.
+func detectRPCVersion(trans *transmission.Client, timeout time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	sess, err := trans.GetSession(ctx, transmission.SessionFieldRPCVersion)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't get transmission session: %s", err)
+	}
+
+	return sess.RPCVersion, nil
+}
+
+func newHandler(trans *transmission.Client, logger log.Logger, opts ...collector.Option) (http.Handler, error) {
+	tc, err := collector.NewTransmissionCollector(trans, logger, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("couldn't create transmission collector: %s", err)
 	}
@@ -53,16 +138,49 @@ func newHandler(turl string, logger log.Logger) (http.Handler, error) {
 		return nil, fmt.Errorf("couldn't register transmission collector: %s", err)
 	}
 
+	// Failed collections are reported with invalid metrics, serve the rest so that
+	// transmission_up and friends are still visible when Transmission is down.
 	handler := promhttp.HandlerFor(
 		prometheus.Gatherers{r},
 		promhttp.HandlerOpts{
-			ErrorHandling: promhttp.HTTPErrorOnError,
+			ErrorLog:      stdlog.New(log.NewStdlibAdapter(level.Warn(logger)), "", 0),
+			ErrorHandling: promhttp.ContinueOnError,
 		},
 	)
 
 	return handler, nil
 }
 
+// selfTest collects metrics once and logs which of them Transmission was able to provide.
+func selfTest(trans *transmission.Client, logger log.Logger, opts ...collector.Option) error {
+	tc, err := collector.NewTransmissionCollector(trans, logger, opts...)
+	if err != nil {
+		return fmt.Errorf("couldn't create transmission collector: %s", err)
+	}
+
+	r := prometheus.NewRegistry()
+	if err := r.Register(tc); err != nil {
+		return fmt.Errorf("couldn't register transmission collector: %s", err)
+	}
+
+	mfs, err := r.Gather()
+	for _, mf := range mfs {
+		level.Info(logger).Log("msg", "Metric collected", "name", mf.GetName(), "series", len(mf.GetMetric()))
+	}
+	if err != nil {
+		errs, ok := err.(prometheus.MultiError)
+		if !ok {
+			errs = prometheus.MultiError{err}
+		}
+		for _, err := range errs {
+			level.Warn(logger).Log("msg", "Metric failed", "err", err)
+		}
+		return fmt.Errorf("%d metric(s) failed to be collected", len(errs))
+	}
+
+	return nil
+}
+
 func must(h http.Handler, err error) http.Handler {
 	if err != nil {
 		panic(err)
@@ -76,10 +194,137 @@ func main() {
 		"web.telemetry-path",
 		"Path under which to expose metrics.",
 	).Default("/metrics").String()
+	enableDebug := kingpin.Flag(
+		"web.enable-debug",
+		"Enable debug endpoints under /debug/.",
+	).Bool()
+	enableProbe := kingpin.Flag(
+		"web.enable-probe",
+		"Enable /probe endpoint that collects metrics from Transmission instance given in the 'target' parameter.",
+	).Bool()
+	enableControl := kingpin.Flag(
+		"web.enable-control",
+		"Enable endpoints under /control/ that change Transmission settings. Requires basic_auth_users in --web.config.file.",
+	).Bool()
+	metricDenylist := kingpin.Flag(
+		"web.metric-denylist",
+		"Regular expression matching names of metrics that should not be exposed. Can be repeated.",
+	).Strings()
+	metricAllowlistFile := kingpin.Flag(
+		"web.metric-allowlist-file",
+		"File listing names of the only metrics that should be exposed, one per line.",
+	).String()
+	configFile := kingpin.Flag(
+		"config.file",
+		"YAML file with Transmission connection settings. Explicitly passed flags take precedence.",
+	).String()
+	var urlSet, usernameSet, passwordSet, timeoutSet bool
 	transmissionURL := kingpin.Flag(
 		"transmission.url",
 		"Transmission RPC server URL",
-	).Default("http://127.0.0.1:9091").String()
+	).Default("http://127.0.0.1:9091").IsSetByUser(&urlSet).String()
+	transmissionUsername := kingpin.Flag(
+		"transmission.username",
+		"Username used to authenticate to Transmission RPC server.",
+	).IsSetByUser(&usernameSet).String()
+	transmissionPassword := kingpin.Flag(
+		"transmission.password",
+		"Password used to authenticate to Transmission RPC server.",
+	).Envar("TRANSMISSION_PASSWORD").IsSetByUser(&passwordSet).String()
+	transmissionPasswordFile := kingpin.Flag(
+		"transmission.password-file",
+		"File containing password used to authenticate to Transmission RPC server. The file is re-read whenever it changes.",
+	).String()
+	transmissionTimeout := kingpin.Flag(
+		"transmission.timeout",
+		"Timeout for Transmission RPC requests made during a scrape.",
+	).Default("5s").IsSetByUser(&timeoutSet).Duration()
+	transmissionCompression := kingpin.Flag(
+		"transmission.compression",
+		"Request gzip-compressed responses from Transmission RPC server.",
+	).Default("true").Bool()
+	tlsCertFile := kingpin.Flag(
+		"transmission.tls.cert-file",
+		"Client certificate file presented to Transmission RPC server over HTTPS.",
+	).String()
+	tlsKeyFile := kingpin.Flag(
+		"transmission.tls.key-file",
+		"Private key file of the client certificate.",
+	).String()
+	tlsCAFile := kingpin.Flag(
+		"transmission.tls.ca-file",
+		"CA certificate file used to verify Transmission RPC server (defaults to system CAs).",
+	).String()
+	tlsInsecureSkipVerify := kingpin.Flag(
+		"transmission.tls.insecure-skip-verify",
+		"Don't verify Transmission RPC server certificate.",
+	).Bool()
+	sshTunnelSpec := kingpin.Flag(
+		"transmission.ssh-tunnel",
+		"SSH server (user@host:port) to tunnel Transmission RPC connections through. Transmission URL is resolved from the SSH server.",
+	).String()
+	sshKeyFile := kingpin.Flag(
+		"transmission.ssh-tunnel.key-file",
+		"Private key file used to authenticate to the SSH server.",
+	).String()
+	sshKnownHostsFile := kingpin.Flag(
+		"transmission.ssh-tunnel.known-hosts-file",
+		"Known hosts file used to verify the SSH server (defaults to ~/.ssh/known_hosts).",
+	).String()
+	portCheckInterval := kingpin.Flag(
+		"transmission.port-check-interval",
+		"How often to ask Transmission to test whether the peer port is open (0 tests on every scrape).",
+	).Default("5m").Duration()
+	freeSpacePaths := kingpin.Flag(
+		"collector.free-space-path",
+		"Additional path to report free space for, as seen by Transmission (the download directory is always reported). Can be repeated.",
+	).Strings()
+	collectorPreset := kingpin.Flag(
+		"collector.preset",
+		"Amount of exposed detail: lite (session-wide metrics), standard (adds metrics aggregated over torrents) or full (adds per-torrent and per-tracker metrics).",
+	).Default("full").Enum("lite", "standard", "full")
+	aggregatesOnly := kingpin.Flag(
+		"collector.aggregates-only",
+		"Only expose metrics aggregated over torrents and trackers, suppressing all per-torrent metrics. Enables the torrents collector unless --no-collector.torrents is given.",
+	).Bool()
+	incompleteAgeThreshold := kingpin.Flag(
+		"collector.incomplete-age-threshold",
+		"Age after which incomplete torrents are considered old.",
+	).Default("720h").Duration()
+	torrentWebseeds := kingpin.Flag(
+		"collector.torrent-webseeds",
+		"Expose per-torrent webseed counts (requires full preset).",
+	).Bool()
+	torrentPeers := kingpin.Flag(
+		"collector.torrent-peers",
+		"Expose metrics derived from per-torrent peer details (requires full preset).",
+	).Bool()
+	torrentFiles := kingpin.Flag(
+		"collector.torrent-files",
+		"Expose metrics derived from per-torrent file details (requires full preset).",
+	).Bool()
+	minTorrentRate := kingpin.Flag(
+		"collector.min-rate-bytes",
+		"Only expose per-torrent metrics for torrents whose combined download and upload rate exceeds this many bytes per second (0 exposes all torrents).",
+	).Default("0").Int64()
+	enabledCollectors := make(map[string]*bool, len(collector.Collectors))
+	collectorsSet := make(map[string]*bool, len(collector.Collectors))
+	for _, c := range collector.Collectors {
+		set := new(bool)
+		enabledCollectors[c.Name] = kingpin.Flag(
+			"collector."+c.Name,
+			"Collect "+c.Help+".",
+		).Default(strconv.FormatBool(c.Default)).IsSetByUser(set).Bool()
+		collectorsSet[c.Name] = set
+	}
+	verboseHelp := kingpin.Flag(
+		"metrics.verbose-help",
+		"Include source Transmission RPC fields into metric help texts.",
+	).Bool()
+	runSelfTest := kingpin.Flag(
+		"self-test",
+		"Collect metrics once, report which of them Transmission provided and exit.",
+	).Bool()
 	toolkitFlags := kingpinflag.AddFlags(kingpin.CommandLine, ":29100")
 
 	promlogConfig := &promlog.Config{}
@@ -91,7 +336,163 @@ func main() {
 
 	level.Info(logger).Log("msg", "Starting transmission-exporter", "version", version.Info())
 
-	http.Handle(*metricsPath, must(newHandler(*transmissionURL, logger)))
+	if *configFile != "" {
+		cfg, err := loadConfig(*configFile)
+		if err != nil {
+			level.Error(logger).Log("err", err)
+			os.Exit(1)
+		}
+
+		cfg.apply(transmissionURL, transmissionUsername, transmissionPassword, transmissionTimeout, explicitSettings{
+			url:      urlSet,
+			username: usernameSet,
+			// Password from the environment is as explicit as the one passed as a flag.
+			password: passwordSet || os.Getenv("TRANSMISSION_PASSWORD") != "",
+			timeout:  timeoutSet,
+		})
+	}
+
+	var tunnel *sshTunnel
+	if *sshTunnelSpec != "" {
+		var err error
+		tunnel, err = newSSHTunnel(*sshTunnelSpec, *sshKeyFile, *sshKnownHostsFile, logger)
+		if err != nil {
+			level.Error(logger).Log("err", err)
+			os.Exit(1)
+		}
+	}
+
+	tlsConfig, err := newTLSConfig(*tlsCertFile, *tlsKeyFile, *tlsCAFile, *tlsInsecureSkipVerify)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		os.Exit(1)
+	}
+
+	denylist := make([]*regexp.Regexp, 0, len(*metricDenylist))
+	for _, expr := range *metricDenylist {
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			level.Error(logger).Log("msg", "invalid metric denylist expression", "expr", expr, "err", err)
+			os.Exit(1)
+		}
+		denylist = append(denylist, re)
+	}
+
+	var allowlist []string
+	if *metricAllowlistFile != "" {
+		var err error
+		allowlist, err = loadMetricAllowlist(*metricAllowlistFile)
+		if err != nil {
+			level.Error(logger).Log("err", err)
+			os.Exit(1)
+		}
+	}
+
+	if *transmissionPassword != "" && *transmissionPasswordFile != "" {
+		level.Error(logger).Log("msg", "--transmission.password and --transmission.password-file are mutually exclusive")
+		os.Exit(1)
+	}
+	hasPassword := *transmissionPassword != "" || *transmissionPasswordFile != ""
+	username, password, passwordFile := *transmissionUsername, *transmissionPassword, *transmissionPasswordFile
+	if (username != "") != hasPassword {
+		level.Warn(logger).Log("msg", "Both username and password are required to authenticate to Transmission, connecting without authentication")
+		username, password, passwordFile = "", "", ""
+	}
+
+	client, err := newClient(clientConfig{
+		url:          *transmissionURL,
+		tunnel:       tunnel,
+		username:     username,
+		password:     password,
+		passwordFile: passwordFile,
+		compression:  *transmissionCompression,
+		tls:          tlsConfig,
+	})
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		os.Exit(1)
+	}
+
+	preset, err := collector.ParsePreset(*collectorPreset)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		os.Exit(1)
+	}
+
+	rpcVersion, err := detectRPCVersion(client, *transmissionTimeout)
+	if err != nil {
+		level.Warn(logger).Log("msg", "Couldn't detect Transmission RPC version, requesting all fields", "err", err)
+	} else {
+		level.Info(logger).Log("msg", "Detected Transmission RPC version", "rpc_version", rpcVersion)
+	}
+
+	// Aggregates are exposed by the torrents collector, so enable it unless the user
+	// explicitly configured it.
+	if *aggregatesOnly && !*collectorsSet["torrents"] {
+		*enabledCollectors["torrents"] = true
+	}
+	var collectors []string
+	for _, c := range collector.Collectors {
+		if *enabledCollectors[c.Name] {
+			collectors = append(collectors, c.Name)
+		}
+	}
+
+	collectorOpts := []collector.Option{
+		collector.WithPreset(preset),
+		collector.WithMetricDenylist(denylist),
+		collector.WithMetricAllowlist(allowlist),
+		collector.WithAggregatesOnly(*aggregatesOnly),
+		collector.WithCollectors(collectors),
+		collector.WithFreeSpacePaths(*freeSpacePaths),
+		collector.WithIncompleteAgeThreshold(*incompleteAgeThreshold),
+		collector.WithTorrentWebseeds(*torrentWebseeds),
+		collector.WithTorrentPeers(*torrentPeers),
+		collector.WithTorrentFiles(*torrentFiles),
+		collector.WithMinTorrentRate(*minTorrentRate),
+		collector.WithVerboseHelp(*verboseHelp),
+		collector.WithRPCVersion(rpcVersion),
+		collector.WithTimeout(*transmissionTimeout),
+		collector.WithPortCheckInterval(*portCheckInterval),
+	}
+
+	if *runSelfTest {
+		if err := selfTest(client, logger, collectorOpts...); err != nil {
+			level.Error(logger).Log("msg", "Self-test failed", "err", err)
+			os.Exit(1)
+		}
+		level.Info(logger).Log("msg", "Self-test passed")
+		return
+	}
+
+	http.Handle(*metricsPath, must(newHandler(client, logger, collectorOpts...)))
+	if *enableProbe {
+		http.Handle("/probe", newProbeHandler(*transmissionCompression, logger, collectorOpts...))
+	}
+	if *enableDebug {
+		turl, err := rpcURL(*transmissionURL)
+		if err != nil {
+			level.Error(logger).Log("err", err)
+			os.Exit(1)
+		}
+		http.Handle("/debug/session", newSessionDebugHandler(client, turl, logger))
+	}
+	if *enableControl {
+		// Control endpoints change Transmission settings, never expose them unauthenticated.
+		auth := false
+		if *toolkitFlags.WebConfigFile != "" {
+			auth, err = webConfigHasAuth(*toolkitFlags.WebConfigFile)
+			if err != nil {
+				level.Error(logger).Log("err", err)
+				os.Exit(1)
+			}
+		}
+		if !auth {
+			level.Error(logger).Log("msg", "Control endpoints require basic_auth_users in --web.config.file")
+			os.Exit(1)
+		}
+		http.Handle("/control/turtle", newTurtleControlHandler(client, logger))
+	}
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`<html>
 			<head><title>Transmission Exporter</title></head>
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pborzenkov/go-transmission/transmission"
+)
+
+func TestNewClientCompression(t *testing.T) {
+	const session = `{"arguments":{"rpc-version":17},"result":"success"}`
+
+	for _, compression := range []bool{true, false} {
+		var gzipped bool
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			gzipped = strings.Contains(r.Header.Get("Accept-Encoding"), "gzip")
+			if !gzipped {
+				w.Write([]byte(session))
+				return
+			}
+
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			gz.Write([]byte(session))
+			gz.Close()
+		}))
+
+		client, err := newClient(clientConfig{url: srv.URL, compression: compression})
+		if err != nil {
+			t.Fatalf("newClient() error = %v", err)
+		}
+		sess, err := client.GetSession(context.Background(), transmission.SessionFieldRPCVersion)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("GetSession() with compression %v error = %v", compression, err)
+		}
+		if sess.RPCVersion != 17 {
+			t.Errorf("RPCVersion with compression %v = %d, want 17", compression, sess.RPCVersion)
+		}
+		if gzipped != compression {
+			t.Errorf("gzip-encoded response with compression %v = %v, want %v", compression, gzipped, compression)
+		}
+	}
+}
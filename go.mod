@@ -9,4 +9,6 @@ require (
 	github.com/prometheus/client_golang v1.20.5
 	github.com/prometheus/common v0.59.1
 	github.com/prometheus/exporter-toolkit v0.11.0
+	golang.org/x/crypto v0.26.0
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pborzenkov/go-transmission/transmission"
+	"github.com/pborzenkov/transmission-exporter/collector"
+)
+
+// newProbeHandler returns handler that collects metrics from Transmission instance
+// passed in the 'target' query parameter. Credentials, password file, TLS client
+// certificate and SSH tunnel of the default instance are never used for probed
+// targets, since the target is chosen by whoever sends the request. Credentials for
+// the target can be passed in the URL itself.
+func newProbeHandler(compression bool, logger log.Logger, opts ...collector.Option) http.Handler {
+	// RPC version detected at startup belongs to the default instance, and a collector
+	// created for a single probe has no state from previous scrapes.
+	opts = append(opts[:len(opts):len(opts)], collector.WithRPCVersion(0), collector.WithStateless(true))
+
+	// Connections to probed targets are reused across probes.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableCompression = !compression
+	httpClient := &http.Client{Transport: transport}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := r.URL.Query().Get("target")
+		u, err := url.Parse(target)
+		if target == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			http.Error(w, "missing or invalid 'target' parameter", http.StatusBadRequest)
+			return
+		}
+
+		options := []transmission.Option{transmission.WithHTTPClient(httpClient)}
+		if u.User != nil {
+			password, _ := u.User.Password()
+			options = append(options, transmission.WithAuth(u.User.Username(), password))
+			u.User = nil
+		}
+		target = u.String()
+		logger := log.With(logger, "target", target)
+
+		client, err := transmission.New(target, options...)
+		if err != nil {
+			level.Warn(logger).Log("msg", "failed to create client", "err", err)
+			http.Error(w, fmt.Sprintf("couldn't create transmission client: %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		handler, err := newHandler(client, logger, opts...)
+		if err != nil {
+			level.Warn(logger).Log("msg", "failed to create handler", "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
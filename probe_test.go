@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/log"
+)
+
+func TestProbeHandler(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		methods = make(map[string]bool)
+		auth    = make(map[string]bool)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		user, password, _ := r.BasicAuth()
+
+		mu.Lock()
+		methods[req.Method] = true
+		auth[user+":"+password] = true
+		mu.Unlock()
+
+		w.Write([]byte(`{"arguments":{},"result":"success"}`))
+	}))
+	defer srv.Close()
+
+	target, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target.User = url.UserPassword("probe", "secret")
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/probe?target="+url.QueryEscape(target.String()), nil)
+	newProbeHandler(true, log.NewNopLogger()).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	body, _ := ioutil.ReadAll(rec.Body)
+	for _, name := range []string{"transmission_up", "transmission_scrape_success"} {
+		if !strings.Contains(string(body), name+" ") {
+			t.Errorf("probe response misses %s", name)
+		}
+	}
+	for _, name := range []string{"transmission_seconds_since_last_success", "transmission_is_port_open", "transmission_port_closed_seconds"} {
+		if strings.Contains(string(body), name+" ") {
+			t.Errorf("probe response contains stateful metric %s", name)
+		}
+	}
+
+	if methods["port-test"] {
+		t.Errorf("probe checked peer port")
+	}
+	if len(auth) != 1 || !auth["probe:secret"] {
+		t.Errorf("probe authenticated as %v, want only probe:secret", auth)
+	}
+}
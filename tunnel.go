@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshTunnel dials connections to Transmission through an SSH server. The SSH
+// session is established lazily and re-established if it drops.
+type sshTunnel struct {
+	addr   string
+	config *ssh.ClientConfig
+	logger log.Logger
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+func newSSHTunnel(spec, keyFile, knownHostsFile string, logger log.Logger) (*sshTunnel, error) {
+	parts := strings.SplitN(spec, "@", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid SSH tunnel %q, expected user@host:port", spec)
+	}
+	user, addr := parts[0], parts[1]
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+
+	key, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read SSH private key: %s", err)
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse SSH private key: %s", err)
+	}
+
+	if knownHostsFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("couldn't locate SSH known hosts: %s", err)
+		}
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't load SSH known hosts: %s", err)
+	}
+
+	return &sshTunnel{
+		addr: addr,
+		config: &ssh.ClientConfig{
+			User:            user,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         10 * time.Second,
+		},
+		logger: logger,
+	}, nil
+}
+
+// DialContext connects to addr on the network as seen from the SSH server.
+func (t *sshTunnel) DialContext(_ context.Context, network, addr string) (net.Conn, error) {
+	client, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := client.Dial(network, addr)
+	if err == nil {
+		return conn, nil
+	}
+
+	// The SSH session might have silently dropped, reconnect and retry once.
+	level.Warn(t.logger).Log("msg", "failed to dial through SSH tunnel, reconnecting", "err", err)
+	t.dropClient(client)
+	if client, err = t.getClient(); err != nil {
+		return nil, err
+	}
+
+	return client.Dial(network, addr)
+}
+
+func (t *sshTunnel) getClient() (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client != nil {
+		return t.client, nil
+	}
+
+	client, err := ssh.Dial("tcp", t.addr, t.config)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't establish SSH tunnel to %s: %s", t.addr, err)
+	}
+	level.Info(t.logger).Log("msg", "SSH tunnel established", "addr", t.addr)
+
+	t.client = client
+	go func() {
+		err := client.Wait()
+		level.Warn(t.logger).Log("msg", "SSH tunnel closed", "addr", t.addr, "err", err)
+		t.dropClient(client)
+	}()
+
+	return client, nil
+}
+
+func (t *sshTunnel) dropClient(client *ssh.Client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client == client {
+		t.client = nil
+	}
+	client.Close()
+}